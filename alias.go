@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// AliasFile returns the name of the file containing user-defined command
+// aliases, or an empty string to disable aliases. By default, aliases are read
+// from "<Bin>/aliases" in the user config directory (e.g. $XDG_CONFIG_HOME).
+// Each non-empty line that does not start with '#' has the format:
+//
+//	name = command [args...]
+//
+// The right-hand side is split into arguments using shell-like quoting rules.
+// When the first argument of the root command is an alias name, it is replaced
+// with the alias arguments. Aliases that would shadow a command registered via
+// Cfg.Add are ignored. The file is read once per process, and invalid lines are
+// reported via Warn and ignored.
+var AliasFile = func() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, Bin, "aliases")
+}
+
+// aliasCache contains aliases loaded from AliasFile.
+var aliasCache struct {
+	sync.Mutex
+	name    string
+	aliases map[string][]string
+}
+
+// userAliases returns user-defined aliases for root command c. The alias file
+// is read once per process. Problems with the file are reported via Warn and
+// invalid lines are ignored.
+func (c *Cfg) userAliases() map[string][]string {
+	if c.parent != nil || c.cmds == nil {
+		return nil
+	}
+	name := AliasFile()
+	if name == "" {
+		return nil
+	}
+	aliasCache.Lock()
+	defer aliasCache.Unlock()
+	if aliasCache.aliases == nil || aliasCache.name != name {
+		aliasCache.name, aliasCache.aliases = name, loadAliases(name)
+	}
+	aliases := make(map[string][]string, len(aliasCache.aliases))
+	for alias, args := range aliasCache.aliases {
+		if c.cmds[alias] == nil && !isHelp(alias) {
+			aliases[alias] = args
+		}
+	}
+	return aliases
+}
+
+// loadAliases reads aliases from the named file. A missing file is not an
+// error.
+func loadAliases(name string) map[string][]string {
+	aliases := make(map[string][]string)
+	f, err := os.Open(name)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			Warn(fmt.Sprintf("cli: failed to read aliases: %v", err))
+		}
+		return aliases
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for line := 1; s.Scan(); line++ {
+		ln := strings.TrimSpace(s.Text())
+		if ln == "" || ln[0] == '#' {
+			continue
+		}
+		alias, args, err := parseAlias(ln)
+		if err != nil {
+			Warn(fmt.Sprintf("%v (%s:%d)", err, name, line))
+			continue
+		}
+		aliases[alias] = args
+	}
+	if err = s.Err(); err != nil {
+		Warn(fmt.Sprintf("cli: failed to read aliases: %v", err))
+	}
+	return aliases
+}
+
+// parseAlias parses a "name = command [args...]" alias definition.
+func parseAlias(ln string) (string, []string, error) {
+	i := strings.IndexByte(ln, '=')
+	if i < 0 {
+		return "", nil, errors.New("cli: missing '=' in alias")
+	}
+	alias := strings.TrimSpace(ln[:i])
+	if alias == "" || alias[0] == '-' || strings.IndexFunc(alias, unicode.IsSpace) >= 0 {
+		return "", nil, fmt.Errorf("cli: invalid alias name %q", alias)
+	}
+	args, err := splitArgs(ln[i+1:])
+	if err == nil && len(args) == 0 {
+		err = fmt.Errorf("cli: empty alias %q", alias)
+	}
+	return alias, args, err
+}
+
+// aliasList writes a list of all user-defined aliases to w.
+func (w *Writer) aliasList(aliases map[string][]string) {
+	names, maxLen := make([]string, 0, len(aliases)), 0
+	for name := range aliases {
+		if names = append(names, name); maxLen < len(name) {
+			maxLen = len(name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args := aliases[name]
+		quoted := make([]string, len(args))
+		for i, arg := range args {
+			if arg == "" || strings.IndexFunc(arg, isSpecial) >= 0 {
+				arg = strconv.Quote(arg)
+			}
+			quoted[i] = arg
+		}
+		fmt.Fprintf(w, "  %-*s  %s\n", maxLen, name, strings.Join(quoted, " "))
+	}
+}
+
+// isSpecial returns true if c requires quoting when printed as part of an
+// argument.
+func isSpecial(c rune) bool { return unicode.IsSpace(c) || c == '\'' || c == '"' || c == '\\' }
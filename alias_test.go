@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAliases(t *testing.T) {
+	name := tmpFile(t)
+	defer os.Remove(name)
+	defer func(fn func() string) { AliasFile = fn }(AliasFile)
+	AliasFile = func() string { return name }
+	defer func() { aliasCache.aliases = nil }()
+	aliasCache.aliases = nil
+	warn, restore := interceptWarn()
+	defer restore()
+	require.NoError(t, ioutil.WriteFile(name, []byte(`
+		# Comment
+		l = list -v
+		ls = list "a b"
+		c2 = c2
+		help = c1
+	`), 0o600))
+
+	var root Cfg
+	c1 := root.Add(&Cfg{Name: "list|c1", MaxArgs: -1, New: func() Cmd { return &boolCmd{} }})
+	c2 := root.Add(&Cfg{Name: "c2", Summary: "Command 2", New: newTestCmd(nil)})
+	sub := c2.Add(&Cfg{Name: "l", New: newTestCmd(nil)})
+
+	cfg, cmd, args, err := root.Parse(split("l x"))
+	require.NoError(t, err)
+	assert.Equal(t, c1, cfg)
+	assert.True(t, cmd.(*boolCmd).V)
+	assert.Equal(t, []string{"x"}, args)
+
+	cfg, _, args, err = root.Parse(split("ls"))
+	require.NoError(t, err)
+	assert.Equal(t, c1, cfg)
+	assert.Equal(t, []string{"a b"}, args)
+
+	// Aliases are only expanded at the root level
+	cfg, _, _, err = root.Parse(split("c2 l"))
+	require.NoError(t, err)
+	assert.Equal(t, sub, cfg)
+
+	cfg, _, _, err = root.Parse(split("help"))
+	assert.Equal(t, ErrHelp, err)
+	assert.Equal(t, &root, cfg)
+
+	Bin = "bin"
+	assert.Equal(t, Dedent(`
+		Usage: bin <command> [options] ...
+		       bin <command> help
+		       bin help [command]
+
+		Commands:
+		  c2    Command 2
+		  list
+
+		Aliases:
+		  l   list -v
+		  ls  list "a b"
+
+	`)[1:], root.Help().String())
+	assert.NotContains(t, c2.Help().String(), "Aliases")
+
+	// The file is only read once
+	require.NoError(t, ioutil.WriteFile(name, []byte("x = c2"), 0o600))
+	_, _, _, err = root.Parse(split("x"))
+	assert.Equal(t, UsageError(`unknown command "x"`), err)
+	assert.Empty(t, *warn)
+
+	for _, tc := range []struct{ data, warn string }{
+		{"x", "cli: missing '=' in alias (" + name + ":1)"},
+		{"\n = y", "cli: invalid alias name \"\" (" + name + ":2)"},
+		{"-x = y", "cli: invalid alias name \"-x\" (" + name + ":1)"},
+		{"x y = z", "cli: invalid alias name \"x y\" (" + name + ":1)"},
+		{"x = 'y", "cli: unterminated quote (" + name + ":1)"},
+		{"x = ", "cli: empty alias \"x\" (" + name + ":1)"},
+	} {
+		require.NoError(t, ioutil.WriteFile(name, []byte(tc.data+"\nok = c2 l"), 0o600))
+		aliasCache.aliases, *warn = nil, nil
+		cfg, _, _, err = root.Parse(split("ok"))
+		assert.NoError(t, err)
+		assert.Equal(t, sub, cfg)
+		assert.Equal(t, []string{tc.warn}, *warn)
+	}
+
+	// Help and flags do not read the alias file
+	aliasCache.aliases, *warn = nil, nil
+	require.NoError(t, ioutil.WriteFile(name, []byte("x"), 0o600))
+	for _, args := range []string{"help", "--help", "-h"} {
+		_, _, _, err = root.Parse(split(args))
+		assert.Equal(t, ErrHelp, err, "%s", args)
+	}
+	_, _, _, err = root.Parse(split("-x"))
+	assert.Equal(t, UsageError(`unknown command "-x"`), err)
+	defer func(d bool, s, f string) { Debug, DebugSpec, DebugFlag = d, s, f }(Debug, DebugSpec, DebugFlag)
+	DebugFlag = "debug"
+	_, _, _, err = root.Parse(split("-debug c2"))
+	assert.NoError(t, err)
+	assert.Empty(t, *warn)
+	assert.Nil(t, aliasCache.aliases)
+
+	// Missing files are not an error
+	require.NoError(t, os.Remove(name))
+	aliasCache.aliases = nil
+	_, _, _, err = root.Parse(split("ls"))
+	assert.Equal(t, UsageError(`unknown command "ls"`), err)
+	assert.Empty(t, *warn)
+}
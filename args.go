@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ResponseFiles enables the expansion of "@file" arguments by Cfg.Parse. Each
+// such argument is replaced with the contents of the file, split into
+// arguments using shell-like quoting rules. "@-" reads arguments from stdin,
+// and "@@" at the start of an argument is replaced with a literal '@'. Files
+// may refer to other files, but not recursively. Arguments after "--" are not
+// expanded, including those that follow a "--" in a response file.
+var ResponseFiles bool
+
+// expandArgs performs response file expansion on args.
+func expandArgs(args []string) ([]string, error) {
+	args, _, err := expandFiles(args, nil)
+	return args, err
+}
+
+// expandFiles expands args, using stack to detect recursion. It stops at the
+// first "--", which may come from a response file, and returns true if one was
+// found so that the caller does not expand its remaining arguments.
+func expandFiles(args []string, stack []string) ([]string, bool, error) {
+	var out []string
+	for i, arg := range args {
+		if arg == "--" {
+			if out == nil {
+				return args, true, nil
+			}
+			return append(out, args[i:]...), true, nil
+		}
+		if len(arg) < 2 || arg[0] != '@' {
+			if out != nil {
+				out = append(out, arg)
+			}
+			continue
+		}
+		if out == nil {
+			out = append(make([]string, 0, len(args)), args[:i]...)
+		}
+		if arg[1] == '@' {
+			out = append(out, arg[1:])
+			continue
+		}
+		name := arg[1:]
+		if name != "-" {
+			if abs, err := filepath.Abs(name); err == nil {
+				name = abs
+			}
+		}
+		for _, prev := range stack {
+			if prev == name {
+				return nil, false, fmt.Errorf("cli: recursive response file: %s", arg[1:])
+			}
+		}
+		var b []byte
+		var err error
+		if name == "-" {
+			b, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			b, err = ioutil.ReadFile(name)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		more, err := splitArgs(string(b))
+		if err != nil {
+			return nil, false, fmt.Errorf("%v in response file: %s", err, arg[1:])
+		}
+		more, end, err := expandFiles(more, append(stack, name))
+		if err != nil {
+			return nil, false, err
+		}
+		if out = append(out, more...); end {
+			return append(out, args[i+1:]...), true, nil
+		}
+	}
+	if out == nil {
+		return args, false, nil
+	}
+	return out, false, nil
+}
+
+// Errors returned by splitArgs for incomplete input.
+var (
+	errQuote  = errors.New("cli: unterminated quote")
+	errEscape = errors.New("cli: unterminated escape")
+)
+
+// splitArgs splits s into arguments separated by white space. Single quotes
+// preserve the literal value of all enclosed characters. Within double
+// quotes, a backslash escapes '"', '\', and '$'. Outside of quotes, a backslash
+// escapes any character. A backslash followed by a newline outside of single
+// quotes is removed to allow line continuation.
+func splitArgs(s string) ([]string, error) { return splitVars(s, nil) }
+
+// splitVars is splitArgs with variable substitution. If lookup is not nil,
+// "$name" and "${name}" outside of single quotes are replaced with variable
+// values. Values are not split into multiple arguments.
+func splitVars(s string, lookup func(name string) (string, bool)) ([]string, error) {
+	var args []string
+	var b strings.Builder
+	inArg, quote, escape := false, rune(0), false
+	for i := 0; i < len(s); {
+		c, n := utf8.DecodeRuneInString(s[i:])
+		switch i += n; {
+		case escape:
+			if escape = false; c == '\n' {
+				break
+			}
+			if quote == '"' && c != '"' && c != '\\' && c != '$' {
+				b.WriteByte('\\')
+			}
+			inArg = true
+			b.WriteRune(c)
+		case c == quote:
+			quote = 0
+		case quote == '\'':
+			b.WriteRune(c)
+		case c == '\\':
+			escape = true
+		case c == '$' && lookup != nil:
+			name, n := varName(s[i:])
+			if n < 0 {
+				return nil, errors.New("cli: unterminated variable")
+			} else if n == 0 {
+				inArg = true
+				b.WriteRune(c)
+				break
+			}
+			v, ok := lookup(name)
+			if !ok {
+				return nil, fmt.Errorf("cli: undefined variable %q", name)
+			}
+			i += n
+			inArg = inArg || v != "" || quote != 0
+			b.WriteString(v)
+		case quote == '"':
+			b.WriteRune(c)
+		case c == '\'' || c == '"':
+			inArg, quote = true, c
+		case unicode.IsSpace(c):
+			if inArg {
+				args = append(args, b.String())
+				b.Reset()
+				inArg = false
+			}
+		default:
+			inArg = true
+			b.WriteRune(c)
+		}
+	}
+	if quote != 0 {
+		return nil, errQuote
+	} else if escape {
+		return nil, errEscape
+	}
+	if inArg {
+		args = append(args, b.String())
+	}
+	return args, nil
+}
+
+// varName returns the variable name at the start of s, which follows a '$',
+// and the number of bytes consumed. It returns -1 for an unterminated "${".
+func varName(s string) (string, int) {
+	if strings.HasPrefix(s, "{") {
+		i := strings.IndexByte(s, '}')
+		if i < 0 {
+			return "", -1
+		}
+		return s[1:i], i + 1
+	}
+	i := 0
+	for i < len(s) && isVarChar(s[i]) {
+		i++
+	}
+	return s[:i], i
+}
+
+// isVarChar returns true if c is a valid variable name character.
+func isVarChar(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z'
+}
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []*struct {
+		in   string
+		want []string
+		err  string
+	}{
+		{in: ""},
+		{in: " \t\n "},
+		{in: "a", want: []string{"a"}},
+		{in: " a  b\nc\t", want: []string{"a", "b", "c"}},
+		{in: `'' ""`, want: []string{"", ""}},
+		{in: `'a b' "c d"`, want: []string{"a b", "c d"}},
+		{in: `a'b'"c"d`, want: []string{"abcd"}},
+		{in: `'a\b "c"'`, want: []string{`a\b "c"`}},
+		{in: `"a\b \"c\" \\"`, want: []string{`a\b "c" \`}},
+		{in: `a\ b \'c\\`, want: []string{"a b", "'c\\"}},
+		{in: "a\\\nb \\\n c \"d\\\ne\"", want: []string{"ab", "c", "de"}},
+		{in: "'a\\\nb'", want: []string{"a\\\nb"}},
+		{in: `"a`, err: "cli: unterminated quote"},
+		{in: `a'b`, err: "cli: unterminated quote"},
+		{in: `a\`, err: "cli: unterminated escape"},
+	}
+	for _, tc := range tests {
+		have, err := splitArgs(tc.in)
+		if tc.err != "" {
+			assert.EqualError(t, err, tc.err, "%q", tc.in)
+		} else if assert.NoError(t, err, "%q", tc.in) {
+			assert.Equal(t, tc.want, have, "%q", tc.in)
+		}
+	}
+}
+
+func TestSplitVars(t *testing.T) {
+	vars := map[string]string{"a": "1", "b_2": "x y", "e": ""}
+	lookup := func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+	tests := []*struct {
+		in   string
+		want []string
+		err  string
+	}{
+		{in: "$a ${a}b $b_2 $e \"$e\"", want: []string{"1", "1b", "x y", ""}},
+		{in: `'$a' "\$a" \$a $ a$ $-`, want: []string{"$a", "$a", "$a", "$", "a$", "$-"}},
+		{in: "$c", err: `cli: undefined variable "c"`},
+		{in: "${a", err: "cli: unterminated variable"},
+	}
+	for _, tc := range tests {
+		have, err := splitVars(tc.in, lookup)
+		if tc.err != "" {
+			assert.EqualError(t, err, tc.err, "%q", tc.in)
+		} else if assert.NoError(t, err, "%q", tc.in) {
+			assert.Equal(t, tc.want, have, "%q", tc.in)
+		}
+	}
+}
+
+func TestExpandArgs(t *testing.T) {
+	dir, err := ioutil.TempDir("", t.Name())
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := func(name, data string) string {
+		name = filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(name, []byte(data), 0o600))
+		return "@" + name
+	}
+	a := file("a", "-x 'y z'\n")
+	b := file("b", "1 "+a+" 2")
+	c := file("c", "@@c "+b)
+	d := file("d", "-- @@d")
+
+	args := split("x @ y")
+	have, err := expandArgs(args)
+	require.NoError(t, err)
+	assert.Equal(t, args, have)
+
+	have, err = expandArgs([]string{"x", a, "@@y", "--", a})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x", "-x", "y z", "@y", "--", a}, have)
+
+	have, err = expandArgs([]string{c, d})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"@c", "1", "-x", "y z", "2", "--", "@@d"}, have)
+
+	// "--" in a nested file stops expansion in all files
+	f := file("f", "x "+d+" "+a+" "+a)
+	have, err = expandArgs([]string{f, a})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x", "--", "@@d", a, a, a}, have)
+
+	_, err = expandArgs([]string{"@" + filepath.Join(dir, "none")})
+	assert.True(t, os.IsNotExist(err), "%v", err)
+
+	file("e", "'")
+	_, err = expandArgs([]string{"@" + filepath.Join(dir, "e")})
+	assert.EqualError(t, err, "cli: unterminated quote in response file: "+filepath.Join(dir, "e"))
+
+	r1 := file("r1", "@"+filepath.Join(dir, "r2"))
+	file("r2", "x "+r1)
+	_, err = expandArgs([]string{r1})
+	assert.EqualError(t, err, "cli: recursive response file: "+r1[1:])
+
+	// Same file twice is not recursion
+	have, err = expandArgs([]string{a, a})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-x", "y z", "-x", "y z"}, have)
+}
+
+func TestExpandArgsStdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer func(f *os.File) { os.Stdin = f }(os.Stdin)
+	os.Stdin = r
+	_, err = w.WriteString("a 'b c'")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	have, err := expandArgs(split("x @- y"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x", "a", "b c", "y"}, have)
+}
+
+func TestParseResponseFiles(t *testing.T) {
+	name := tmpFile(t)
+	defer os.Remove(name)
+	require.NoError(t, ioutil.WriteFile(name, []byte("c -v 'a b'"), 0o600))
+
+	var main Cfg
+	c := main.Add(&Cfg{Name: "c", MaxArgs: -1, New: func() Cmd { return &boolCmd{} }})
+	_, _, args, err := main.Parse([]string{"@" + name})
+	assert.Equal(t, UsageError(`unknown command "@`+name+`"`), err)
+	assert.Nil(t, args)
+
+	defer func(v bool) { ResponseFiles = v }(ResponseFiles)
+	ResponseFiles = true
+	cfg, cmd, args, err := main.Parse([]string{"@" + name, "x"})
+	require.NoError(t, err)
+	assert.Equal(t, c, cfg)
+	assert.True(t, cmd.(*boolCmd).V)
+	assert.Equal(t, []string{"a b", "x"}, args)
+
+	_, _, _, err = main.Parse([]string{"@" + name + ".none"})
+	assert.True(t, os.IsNotExist(err), "%v", err)
+}
+
+type boolCmd struct {
+	V bool `cli:"Verbose"`
+}
+
+func (*boolCmd) Main([]string) error { return nil }
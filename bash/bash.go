@@ -102,10 +102,9 @@ type cmdSpec struct {
 func newCmdSpec(m map[string]*cmdSpec, root string, c *cli.Cfg) {
 	names := strings.Split(c.Name, "|")
 	cs := &cmdSpec{Name: safeName(names[0])}
-	if len(names) > 1 {
-		cs.Refs = names[1:]
-		for i := range cs.Refs {
-			cs.Refs[i] = root + safeName(cs.Refs[i])
+	for _, name := range names[1:] {
+		if !strings.HasPrefix(name, "~") {
+			cs.Refs = append(cs.Refs, root+safeName(name))
 		}
 	}
 	var spec strings.Builder
@@ -114,11 +113,18 @@ func newCmdSpec(m map[string]*cmdSpec, root string, c *cli.Cfg) {
 		root += cs.Name + "_"
 		names = append(make([]string, 0, 1+len(cmds)), "help")
 		for _, c := range cmds {
-			if !c.Hide {
+			if !c.Hide && c.Deprecated == "" {
 				newCmdSpec(m, root, c)
 				names = append(names, cli.Name(c))
 			}
 		}
+		for _, name := range c.Plugins() {
+			m[root+safeName(name)] = &cmdSpec{
+				Name: safeName(name),
+				Spec: "-W '' -o bashdefault",
+			}
+			names = append(names, name)
+		}
 		sort.Strings(names)
 		spec.WriteString(names[0])
 		for _, name := range names[1:] {
@@ -127,7 +133,10 @@ func newCmdSpec(m map[string]*cmdSpec, root string, c *cli.Cfg) {
 		}
 	} else {
 		root += cs.Name
-		cli.NewFlagSet(cli.New(c)).VisitAll(func(f *flag.Flag) {
+		for _, f := range cli.Flags(cli.New(c)) {
+			if f.Hidden || f.Deprecated != "" {
+				continue
+			}
 			if cs.Args == nil {
 				cs.Args = make(map[string]string)
 			} else {
@@ -135,11 +144,19 @@ func newCmdSpec(m map[string]*cmdSpec, root string, c *cli.Cfg) {
 			}
 			spec.WriteByte('-')
 			spec.WriteString(f.Name)
+			if f.Negatable {
+				spec.WriteString(" -no-")
+				spec.WriteString(f.Name)
+			}
+			for _, alias := range f.Alias {
+				spec.WriteString(" -")
+				spec.WriteString(alias)
+			}
 			if b, ok := f.Value.(boolFlag); ok && b.IsBoolFlag() {
-				return
+				continue
 			}
 			var argSpec string
-			switch arg, _ := flag.UnquoteUsage(f); arg {
+			switch arg, _ := flag.UnquoteUsage(f.Flag); arg {
 			case "file":
 				argSpec = "-f"
 			case "dir":
@@ -148,7 +165,10 @@ func newCmdSpec(m map[string]*cmdSpec, root string, c *cli.Cfg) {
 				argSpec = "-W ''"
 			}
 			cs.Args[safeName(f.Name)] = argSpec
-		})
+			for _, alias := range f.Alias {
+				cs.Args[safeName(alias)] = argSpec
+			}
+		}
 	}
 	spec.WriteByte('\'')
 	if c.MaxArgs > 0 || c.MaxArgs < c.MinArgs {
@@ -1,16 +1,21 @@
 package bash
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/mxk/go-cli"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCompgen(t *testing.T) {
 	var main cli.Cfg
 	main.Add(&cli.Cfg{
-		Name: "cmd1|c1",
+		Name: "cmd1|c1|~old",
 		New:  func() cli.Cmd { return new(cmd1) },
 	})
 	main.Add(&cli.Cfg{
@@ -19,18 +24,23 @@ func TestCompgen(t *testing.T) {
 		Name:    "cmd-2",
 		MinArgs: 1,
 	})
+	main.Add(&cli.Cfg{
+		Name:       "cmd0",
+		Deprecated: "cmd1",
+	})
 	want := map[string]*cmdSpec{
 		"_": {
 			Spec: "-W 'cmd1 grp help'",
 		},
 		"_cmd1": {
 			Name: "cmd1",
-			Spec: "-W '-b -d -f -x-z'",
+			Spec: "-W '-b -no-b -d -f -file -v -x-z'",
 			Refs: []string{"_c1"},
 			Args: map[string]string{
-				"f":   "-f",
-				"d":   "-d",
-				"x_z": "-W ''",
+				"f":    "-f",
+				"file": "-f",
+				"d":    "-d",
+				"x_z":  "-W ''",
 			},
 		},
 		"_grp_": {
@@ -52,11 +62,49 @@ func TestCompgen(t *testing.T) {
 	assert.Contains(t, string(b), "complete -F")
 }
 
+func TestCompgenPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires executable bit")
+	}
+	dir, err := ioutil.TempDir("", t.Name())
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	require.NoError(t, os.Setenv("PATH", dir))
+	defer func(bin string) { cli.Bin = bin }(cli.Bin)
+	cli.Bin = "bin"
+	defer func(v bool) { cli.EnablePlugins = v }(cli.EnablePlugins)
+	cli.EnablePlugins = true
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "bin-plug-in"), nil, 0o755))
+
+	var main cli.Cfg
+	main.Add(&cli.Cfg{Name: "cmd"})
+	want := map[string]*cmdSpec{
+		"_": {
+			Spec: "-W 'cmd help plug-in'",
+		},
+		"_cmd": {
+			Name: "cmd",
+			Spec: "-W ''",
+		},
+		"_plug_in": {
+			Name: "plug_in",
+			Spec: "-W '' -o bashdefault",
+		},
+	}
+	have := make(map[string]*cmdSpec)
+	newCmdSpec(have, "", &main)
+	assert.Equal(t, want, have)
+}
+
 type cmd1 struct {
-	B  bool   `cli:"bool"`
-	F  string `cli:"{file}"`
-	D  string `cli:"{dir}"`
-	XZ string `cli:"x-z,"`
+	B  bool      `cli:"bool"`
+	F  string    `cli:"f|file|~in,{file}"`
+	D  string    `cli:"{dir}"`
+	XZ string    `cli:"x-z,"`
+	O  string    `cli:",deprecated=-x-z,"`
+	H  bool      `cli:",hidden,"`
+	V  cli.Count `cli:"v,"`
 }
 
 func (*cmd1) Main(args []string) error { return nil }
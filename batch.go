@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Batch executes a script of command lines from a Cfg tree sequentially. The
+// script uses the same quoting rules as Shell. Lines starting with '#' are
+// comments. Lines of the form "name=value" assign variables, which are
+// substituted as "$name" or "${name}" in subsequent lines. Variables are looked
+// up in Vars, followed by the environment. Undefined variables are an error.
+type Batch struct {
+	Cfg      *Cfg              // Root command
+	Continue bool              // Continue executing commands after errors
+	Status   bool              // Report the exit status of every command
+	Vars     map[string]string // Variable values
+	Out      io.Writer         // Help, error, and status output (default Execute stderr)
+}
+
+// BatchCmd returns a new "batch" command config, which executes script files
+// against the root of the command tree that it is added to:
+//
+//	cli.Main.Add(cli.BatchCmd())
+func BatchCmd() *Cfg {
+	c := &Cfg{
+		Name:    "batch",
+		Usage:   "[options] <file> ...",
+		Summary: "Execute commands from script files",
+		MinArgs: 1,
+		MaxArgs: -1,
+	}
+	c.New = func() Cmd { return &batchCmd{cfg: c} }
+	return c
+}
+
+// batchCmd implements the command returned by BatchCmd.
+type batchCmd struct {
+	cfg      *Cfg
+	Continue bool              `cli:"keep-going|k,Continue executing commands after errors"`
+	Status   bool              `cli:"Report the exit status of every command"`
+	Var      map[string]string `cli:"Define variable {name=value}"`
+}
+
+func (cmd *batchCmd) Help(w *Writer) {
+	w.Text(`
+	Execute commands from script files. Use "-" to read from stdin. Each line
+	is split into arguments using shell-like quoting rules. Lines starting with
+	'#' are comments. Lines of the form "name=value" assign variables, which are
+	substituted as "$name" or "${name}" in subsequent lines.
+	`)
+}
+
+func (cmd *batchCmd) Main(args []string) error {
+	root := cmd.cfg
+	for root.parent != nil {
+		root = root.parent
+	}
+	b := Batch{Cfg: root, Continue: cmd.Continue, Status: cmd.Status, Vars: cmd.Var}
+	var code ExitCode
+	for _, name := range args {
+		if err := b.RunFile(name); err != nil {
+			rc, ok := err.(ExitCode)
+			if !ok {
+				// Report each failure as it happens so that none are lost
+				// with -k
+				ReportError(errWriter(), err)
+				rc = ExitCode(errorCode(err))
+			}
+			if code == 0 {
+				code = rc
+			}
+			if !b.Continue {
+				break
+			}
+		}
+	}
+	if code != 0 {
+		return code
+	}
+	return nil
+}
+
+// RunFile executes a script file. If name is "-", the script is read from
+// stdin.
+func (b *Batch) RunFile(name string) error {
+	if Stdio(name) {
+		return b.Run(os.Stdin, "<stdin>")
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return b.Run(f, name)
+}
+
+// Run executes a script from r. The name is used for status and error
+// reporting. If any command fails, Run returns ExitCode with the exit code of
+// the first failed command.
+func (b *Batch) Run(r io.Reader, name string) error {
+	out := b.Out
+	if out == nil {
+		out = errWriter()
+	}
+	br := bufio.NewReader(r)
+	code := 0
+	for ln, next := 1, 1; ; ln = next {
+		line, err := readLine(br, nil, "", "")
+		next += strings.Count(line, "\n")
+		if line = strings.TrimSpace(line); line != "" && line[0] != '#' {
+			rc := b.exec(out, line)
+			if b.Status || rc != 0 {
+				fmt.Fprintf(out, "%s:%d: exit status %d\n", name, ln, rc)
+			}
+			if rc != 0 {
+				if code == 0 {
+					code = rc
+				}
+				if !b.Continue {
+					break
+				}
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				return err
+			}
+			break
+		}
+	}
+	if code != 0 {
+		return ExitCode(code)
+	}
+	return nil
+}
+
+// exec executes one script line and returns its exit code.
+func (b *Batch) exec(out io.Writer, line string) int {
+	if i := strings.IndexByte(line, '='); i > 0 && isVarName(line[:i]) {
+		v, err := splitVars(line[i+1:], b.lookup)
+		if err == nil && len(v) > 1 {
+			err = fmt.Errorf("cli: multiple values assigned to %q", line[:i])
+		}
+		if err != nil {
+			fmt.Fprintf(out, "Error: %v\n", err)
+			return 1
+		}
+		if b.Vars == nil {
+			b.Vars = make(map[string]string)
+		}
+		b.Vars[line[:i]] = strings.Join(v, "")
+		return 0
+	}
+	args, err := splitVars(line, b.lookup)
+	if err != nil {
+		fmt.Fprintf(out, "Error: %v\n", err)
+		return 1
+	}
+	return dispatch(b.Cfg, out, args)
+}
+
+// lookup returns the value of variable name.
+func (b *Batch) lookup(name string) (string, bool) {
+	if v, ok := b.Vars[name]; ok {
+		return v, true
+	}
+	return os.LookupEnv(name)
+}
+
+// isVarName returns true if s is a valid variable name.
+func isVarName(s string) bool {
+	if s == "" || '0' <= s[0] && s[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isVarChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch(t *testing.T) {
+	defer func(fn func(int)) { Exit = fn }(Exit)
+	rc := resetExit()
+	defer os.Unsetenv("CLI_TEST_VAR")
+	require.NoError(t, os.Setenv("CLI_TEST_VAR", "env"))
+
+	var root Cfg
+	var ran [][]string
+	root.Add(&Cfg{Name: "echo", MaxArgs: -1, New: newTestCmd(func(args []string) error {
+		ran = append(ran, args)
+		return nil
+	})})
+	root.Add(&Cfg{Name: "fail", New: newTestCmd(func([]string) error {
+		return errors.New("failed")
+	})})
+	root.Add(&Cfg{Name: "code", New: newTestCmd(func([]string) error {
+		return ExitCode(3)
+	})})
+	script := Dedent(`
+		# Comment
+		x=1
+		y="a b"
+		echo $x "$y" '$y' \$x ${CLI_TEST_VAR}s $x$z
+
+		echo "multi
+		line" $x
+		code
+		fail
+		echo $undefined
+		echo done
+	`)
+
+	var out bytes.Buffer
+	b := Batch{Cfg: &root, Vars: map[string]string{"z": "2"}, Out: &out}
+	assert.Equal(t, ExitCode(3), b.Run(strings.NewReader(script), "test"))
+	assert.Equal(t, [][]string{
+		{"1", "a b", "$y", "$x", "envs", "12"},
+		{"multi\nline", "1"},
+	}, ran)
+	assert.Equal(t, "test:9: exit status 3\n", out.String())
+	assert.Equal(t, map[string]string{"x": "1", "y": "a b", "z": "2"}, b.Vars)
+
+	ran = nil
+	out.Reset()
+	b = Batch{Cfg: &root, Continue: true, Status: true, Out: &out}
+	assert.Equal(t, ExitCode(1), b.Run(strings.NewReader(script), "test"))
+	assert.Equal(t, [][]string{
+		{"multi\nline", "1"},
+		{"done"},
+	}, ran)
+	assert.Equal(t, Dedent(`
+		test:3: exit status 0
+		test:4: exit status 0
+		Error: cli: undefined variable "z"
+		test:5: exit status 1
+		test:7: exit status 0
+		test:9: exit status 3
+		Error: failed
+		test:10: exit status 1
+		Error: cli: undefined variable "undefined"
+		test:11: exit status 1
+		test:12: exit status 0
+	`)[1:], out.String())
+	assert.Equal(t, -1, *rc)
+
+	out.Reset()
+	b = Batch{Cfg: &root, Out: &out}
+	require.NoError(t, b.Run(strings.NewReader("x=\necho \"$x\""), "test"))
+	assert.Equal(t, [][]string{{""}}, ran[len(ran)-1:])
+	assert.Equal(t, ExitCode(1), b.Run(strings.NewReader("x=a b\necho"), "test"))
+	assert.Equal(t, "Error: cli: multiple values assigned to \"x\"\ntest:1: exit status 1\n", out.String())
+}
+
+func TestBatchCmd(t *testing.T) {
+	defer func(fn func(int)) { Exit = fn }(Exit)
+	rc := resetExit()
+	name := tmpFile(t)
+	defer os.Remove(name)
+	require.NoError(t, ioutil.WriteFile(name, []byte("echo $x\ncode\necho y\n"), 0o600))
+
+	var root Cfg
+	var ran [][]string
+	root.Add(&Cfg{Name: "echo", MaxArgs: -1, New: newTestCmd(func(args []string) error {
+		ran = append(ran, args)
+		return nil
+	})})
+	root.Add(&Cfg{Name: "code", New: newTestCmd(func([]string) error {
+		return ExitCode(3)
+	})})
+	root.Add(BatchCmd())
+
+	out := interceptWrite(&os.Stderr)
+	root.Run("batch", "-k", "-var", "x=1", name, name+".none")
+	stderr := out()
+	assert.True(t, strings.HasPrefix(stderr, name+":2: exit status 3\nError: open "+name+".none"), "%s", stderr)
+	assert.Equal(t, 3, *rc)
+	assert.Equal(t, [][]string{{"1"}, {"y"}}, ran)
+
+	// Each failure is reported once as it happens
+	var b bytes.Buffer
+	ran = nil
+	assert.Equal(t, 1, root.Execute(nil, &b, split("batch -k -var x=2 "+name+".none "+name+".nil "+name)))
+	assert.Equal(t, 1, strings.Count(b.String(), "Error: open "+name+".none"), "%s", b.String())
+	assert.Equal(t, 1, strings.Count(b.String(), "Error: open "+name+".nil"), "%s", b.String())
+	assert.True(t, strings.HasSuffix(b.String(), name+":2: exit status 3\n"), "%s", b.String())
+	assert.Equal(t, [][]string{{"2"}, {"y"}}, ran)
+
+	// Without -k, the first failure stops execution
+	b.Reset()
+	ran = nil
+	assert.Equal(t, 1, root.Execute(nil, &b, split("batch "+name+".none "+name)))
+	assert.True(t, strings.HasPrefix(b.String(), "Error: open "+name+".none"), "%s", b.String())
+	assert.Empty(t, ran)
+}
@@ -2,7 +2,9 @@ package cli
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strings"
@@ -30,13 +32,23 @@ type Helper interface {
 //
 //	func (cmd *exampleCmd) Main(args []string) error { return nil }
 type Cfg struct {
-	Name    string     // '|'-separated command name and optional aliases
-	Usage   string     // Option and argument syntax
-	Summary string     // Capitalized one-line description without trailing period
-	MinArgs int        // Minimum number of positional arguments
-	MaxArgs int        // Maximum number of positional arguments
-	Hide    bool       // Hide from command list
-	New     func() Cmd // Constructor (optional for parent commands)
+	Name       string     // '|'-separated command name and optional aliases
+	Usage      string     // Option and argument syntax
+	Summary    string     // Capitalized one-line description without trailing period
+	MinArgs    int        // Minimum number of positional arguments
+	MaxArgs    int        // Maximum number of positional arguments
+	Hide       bool       // Hide from command list
+	Deprecated string     // Replacement hint for deprecated commands
+	New        func() Cmd // Constructor (optional for parent commands)
+
+	// Before and After are optional hooks that are called before and after
+	// Main of this and all descendant commands. They receive the config of the
+	// command being executed. Before hooks are called from the root down, and
+	// an error aborts execution. After hooks are called in reverse order, only
+	// if the Before hook of the same config succeeded, and may replace the
+	// error returned by Main.
+	Before func(c *Cfg, cmd Cmd, args []string) error
+	After  func(c *Cfg, cmd Cmd, args []string, err error) error
 
 	parent *Cfg            // Parent command
 	cmds   map[string]*Cfg // Sub-commands
@@ -53,6 +65,11 @@ func New(c *Cfg) Cmd {
 // nameSep is the command name separator.
 const nameSep = '|'
 
+// deprecatedAlias is the prefix that marks deprecated command aliases in
+// Cfg.Name (e.g. "remove|rm|~del"). A deprecated alias continues to work, but
+// its use triggers a warning suggesting the primary name instead.
+const deprecatedAlias = '~'
+
 // Name returns the first entry in c.Name.
 func Name(c *Cfg) string {
 	if i := strings.IndexByte(c.Name, nameSep); i > 0 {
@@ -73,28 +90,65 @@ func (c *Cfg) Add(child *Cfg) *Cfg {
 		if i = strings.IndexByte(name, nameSep); i < 0 {
 			i = len(name)
 		}
-		if i == 0 {
+		alias := name[:i]
+		if alias != "" && alias[0] == deprecatedAlias {
+			if len(name) == len(child.Name) {
+				panic("cli: primary command name cannot be deprecated")
+			}
+			alias = alias[1:]
+		}
+		if alias == "" {
 			panic("cli: missing command name")
 		}
-		if _, dup := c.cmds[name[:i]]; dup {
-			panic("cli: duplicate command name: " + name[:i])
+		if _, dup := c.cmds[alias]; dup {
+			panic("cli: duplicate command name: " + alias)
 		}
-		if c.cmds[name[:i]] = child; i == len(name) {
+		if c.cmds[alias] = child; i == len(name) {
 			return child
 		}
 	}
 }
 
 // Parse instantiates the requested command and parses the arguments. It returns
-// the command, positional arguments, and any UsageError or ErrHelp.
+// the command, positional arguments, and any UsageError or ErrHelp. Response
+// files are expanded first if ResponseFiles is set, followed by user-defined
+// aliases (see AliasFile). If EnablePlugins is set, unknown commands are
+// resolved as external plugins, which receive all remaining arguments without
+// parsing.
 func (c *Cfg) Parse(args []string) (*Cfg, Cmd, []string, error) {
-	// Find sub-command
 	var err error
+	if ResponseFiles {
+		if args, err = expandArgs(args); err != nil {
+			return c, New(c), nil, err
+		}
+	}
+	if len(args) > 0 && c.parent == nil && c.cmds[args[0]] == nil &&
+		!isHelp(args[0]) && !strings.HasPrefix(args[0], "-") {
+		if alias := c.userAliases()[args[0]]; alias != nil {
+			args = append(alias[:len(alias):len(alias)], args[1:]...)
+		}
+	}
+
+	// Find sub-command
 	for len(args) > 0 && c.cmds != nil {
 		if v := args[0]; isHelp(v) {
 			err = ErrHelp
+		} else if dv, ok := debugFlagValue(v); ok {
+			if e := setDebug(dv); e != nil {
+				err = UsageError(e.Error())
+				break
+			}
 		} else if sub := c.cmds[v]; sub != nil {
-			c = sub
+			if c = sub; c.Deprecated != "" {
+				Warn(fmt.Sprintf("%q is deprecated, use %q instead", v, c.Deprecated))
+			} else if c.isDeprecated(v) {
+				Warn(fmt.Sprintf("%q is deprecated, use %q instead", v, Name(c)))
+			}
+		} else if sub, cmd := c.plugin(v); sub != nil {
+			if args = args[1:]; err == ErrHelp {
+				args = []string{"--help"}
+			}
+			return sub, cmd, args, nil
 		} else if len(v) > 0 {
 			err = Errorf("unknown command %q", v)
 			break
@@ -108,8 +162,11 @@ func (c *Cfg) Parse(args []string) (*Cfg, Cmd, []string, error) {
 	// Parse options
 	cmd := New(c)
 	if err == nil && len(args) > 0 {
-		fs := NewFlagSet(cmd)
-		if err = fs.Parse(args); err != nil && err != ErrHelp {
+		fs := newFlagSet(cmd)
+		if DebugFlag != "" && fs.Lookup(DebugFlag) == nil {
+			fs.Var(debugValue{}, DebugFlag, "")
+		}
+		if err = fs.parse(args); err != nil && err != ErrHelp {
 			err = UsageError(err.Error())
 		}
 		args = fs.Args()
@@ -138,34 +195,100 @@ func (c *Cfg) Run(args ...string) {
 	if args == nil {
 		args = os.Args[1:]
 	}
-	c, cmd, args, err := c.Parse(args)
+	Exit(c.Execute(os.Stdout, os.Stderr, args))
+}
+
+// Execute parses the arguments, runs the requested command, and returns the
+// exit code without calling Exit. Help, error, and crash messages are written
+// to stderr, and so are warnings and log messages while the default Warn and a
+// nil LogOutput are in effect. Plugin commands and the Shell and Batch commands
+// use stdout and stderr as well. Other commands write to os.Stdout and
+// os.Stderr directly. If stdout or stderr is nil, os.Stdout or os.Stderr is
+// used. Execute must not be called concurrently. If the command panics, a
+// crash report is written (see CrashDir) and the exit code is 2.
+func (c *Cfg) Execute(stdout, stderr io.Writer, argv []string) int {
+	saved := execOut
+	defer func() { execOut = saved }()
+	execOut.stdout, execOut.stderr = stdout, stderr
+	c, cmd, args, err := c.Parse(argv)
+	w := newWriter(c)
 	if err == nil {
-		if err = cmd.Main(args); err == nil {
-			Exit(0)
-			return
+		if err = c.runSafe(cmd, args, argv); err == nil {
+			return 0
 		}
 	}
+	out := errWriter()
 	if err == ErrHelp {
-		w := newWriter(c)
-		defer w.done(os.Stderr, 0)
-		w.help()
-	} else {
-		switch e := err.(type) {
-		case UsageError:
-			w := newWriter(c)
-			defer w.done(os.Stderr, 2)
-			w.error(string(e))
-		case ExitCode:
-			Exit(int(e))
-		default:
-			verb := "%v"
-			if Debug {
-				verb = "%+v"
+		return w.flush(out, w.render(w.help, 0))
+	}
+	switch e := err.(type) {
+	case UsageError:
+		return w.flush(out, w.render(func() { w.error(string(e)) }, 2))
+	case ExitCode:
+		return int(e)
+	default:
+		ReportError(out, err)
+		return errorCode(err)
+	}
+}
+
+// execOut contains the writers of the active Execute call.
+var execOut struct{ stdout, stderr io.Writer }
+
+// outWriter returns the stdout writer of the active Execute call or os.Stdout.
+func outWriter() io.Writer {
+	if execOut.stdout != nil {
+		return execOut.stdout
+	}
+	return os.Stdout
+}
+
+// errWriter returns the stderr writer of the active Execute call or os.Stderr.
+func errWriter() io.Writer {
+	if execOut.stderr != nil {
+		return execOut.stderr
+	}
+	return os.Stderr
+}
+
+// errorCode returns the exit code for an error reported via ReportError.
+func errorCode(err error) int {
+	var ec ExitCoder
+	if errors.As(err, &ec) && ec.ExitCode() != 0 {
+		return ec.ExitCode()
+	}
+	return 1
+}
+
+// run sets Verbosity for commands that embed LogFlags and calls cmd.Main,
+// wrapped by the Before and After hooks of c and its parents.
+func (c *Cfg) run(cmd Cmd, args []string) error {
+	if v, ok := cmd.(verbosityCmd); ok {
+		Verbosity = v.verbosity()
+	}
+	var hooks []*Cfg
+	for h := c; h != nil; h = h.parent {
+		if h.Before != nil || h.After != nil {
+			hooks = append(hooks, h)
+		}
+	}
+	var call func(i int) error
+	call = func(i int) (err error) {
+		if i < 0 {
+			return cmd.Main(args)
+		}
+		h := hooks[i]
+		if h.Before != nil {
+			if err = h.Before(c, cmd, args); err != nil {
+				return
 			}
-			fmt.Fprintf(os.Stderr, "Error: "+verb+"\n", err)
-			Exit(1)
 		}
+		if err = call(i - 1); h.After != nil {
+			err = h.After(c, cmd, args, err)
+		}
+		return
 	}
+	return call(len(hooks) - 1)
 }
 
 // Help returns a buffer containing command help information.
@@ -190,6 +313,31 @@ func (c *Cfg) Children() []*Cfg {
 	return cmds
 }
 
+// isDeprecated returns true if alias is marked as deprecated in c.Name.
+func (c *Cfg) isDeprecated(alias string) bool {
+	for _, name := range strings.Split(c.Name, string(nameSep)) {
+		if name != "" && name[0] == deprecatedAlias && name[1:] == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// aliases returns c.Name without deprecated aliases.
+func (c *Cfg) aliases() string {
+	if strings.IndexByte(c.Name, deprecatedAlias) < 0 {
+		return c.Name
+	}
+	names := strings.Split(c.Name, string(nameSep))
+	keep := names[:0]
+	for _, name := range names {
+		if name == "" || name[0] != deprecatedAlias {
+			keep = append(keep, name)
+		}
+	}
+	return strings.Join(keep, string(nameSep))
+}
+
 // fullName returns the fully qualified command name consisting of the prefix,
 // primary names of all parents, the primary command name, and any aliases.
 func (c *Cfg) fullName(prefix string) string {
@@ -203,10 +351,10 @@ func (c *Cfg) fullName(prefix string) string {
 		}
 	}
 	if walk(c.parent); c.Name != "" {
-		if b = append(b, ' '); strings.IndexByte(c.Name, nameSep) == -1 {
-			b = append(b, c.Name...)
+		if name := c.aliases(); strings.IndexByte(name, nameSep) == -1 {
+			b = append(append(b, ' '), name...)
 		} else {
-			b = append(append(append(b, '{'), c.Name...), '}')
+			b = append(append(append(b, " {"...), name...), '}')
 		}
 	}
 	return strings.TrimSpace(string(b))
@@ -217,12 +365,15 @@ type nilCmd Cfg
 
 func (cmd *nilCmd) Main(args []string) error {
 	w := newWriter((*Cfg)(cmd))
-	defer w.done(os.Stderr, 2)
+	return ExitCode(w.flush(errWriter(), w.render(func() { cmd.list(&w) }, 2)))
+}
+
+// list writes the list of available sub-commands to w.
+func (cmd *nilCmd) list(w *Writer) {
 	if cmd.cmds == nil {
 		w.WriteString("Command not implemented\n")
 	} else {
 		w.WriteString("Specify command:\n")
 		w.commands()
 	}
-	return nil
 }
@@ -1,8 +1,10 @@
 package cli
 
 import (
+	"bytes"
 	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"strings"
 	"testing"
@@ -31,6 +33,35 @@ func TestCfgAdd(t *testing.T) {
 	assert.PanicsWithValue(t, "cli: missing command name", func() { main.Add(&Cfg{}) })
 	assert.PanicsWithValue(t, "cli: missing command name", func() { main.Add(&Cfg{Name: "c2|"}) })
 	assert.PanicsWithValue(t, "cli: duplicate command name: c1", func() { main.Add(&Cfg{Name: "x|c1|y"}) })
+	assert.PanicsWithValue(t, "cli: duplicate command name: c1", func() { main.Add(&Cfg{Name: "z|~c1"}) })
+	assert.PanicsWithValue(t, "cli: missing command name", func() { main.Add(&Cfg{Name: "w|~"}) })
+	assert.PanicsWithValue(t, "cli: primary command name cannot be deprecated", func() { main.Add(&Cfg{Name: "~x"}) })
+}
+
+func TestDeprecated(t *testing.T) {
+	warn, restore := interceptWarn()
+	defer restore()
+
+	var main Cfg
+	c1 := main.Add(&Cfg{Name: "c1|~old|c", New: newTestCmd(nil)})
+	main.Add(&Cfg{Name: "c2", Deprecated: "c1", New: newTestCmd(nil)})
+	assert.Equal(t, "bin {c1|c}", c1.fullName("bin"))
+
+	cfg, _, _, err := main.Parse(split("c"))
+	require.NoError(t, err)
+	assert.Equal(t, c1, cfg)
+	assert.Empty(t, *warn)
+
+	cfg, _, _, err = main.Parse(split("old"))
+	require.NoError(t, err)
+	assert.Equal(t, c1, cfg)
+	assert.Equal(t, []string{`"old" is deprecated, use "c1" instead`}, *warn)
+
+	*warn = nil
+	cfg, _, _, err = main.Parse(split("c2"))
+	require.NoError(t, err)
+	assert.Equal(t, "c2", Name(cfg))
+	assert.Equal(t, []string{`"c2" is deprecated, use "c1" instead`}, *warn)
 }
 
 func TestCmd(t *testing.T) {
@@ -112,59 +143,156 @@ func TestCmd(t *testing.T) {
 	assert.EqualError(t, err, "command accepts at most 2 argument(s)")
 }
 
-func TestExit(t *testing.T) {
-	defer func() { Exit = os.Exit }()
+func TestExecute(t *testing.T) {
+	defer func(fn func(int)) { Exit = fn }(Exit)
+	rc := resetExit()
 	var err error
-	cfg := Cfg{New: newTestCmd(func([]string) error { return err })}
-	noArgs := make([]string, 0)
-
+	var main Cfg
+	main.Add(&Cfg{Name: "c1", New: newTestCmd(func([]string) error { return err })})
+	main.Add(&Cfg{Name: "c2", New: func() Cmd { return &panicHelpCmd{} }})
+	main.Add(&Cfg{Name: "c3|~old", New: newTestCmd(nil)})
 	Bin = "bin"
-	err = ErrHelp
-	done, rc := interceptWrite(&os.Stderr), resetExit()
-	cfg.Run(noArgs...)
-	assert.Equal(t, "Usage: bin\n       bin help\n\n", done())
-	assert.Equal(t, 2, *rc)
-
-	err = UsageError("usage error")
-	done, rc = interceptWrite(&os.Stderr), resetExit()
-	cfg.Run(noArgs...)
-	assert.Equal(t, "Error: usage error\nUsage: bin\n       bin help\n", done())
-	assert.Equal(t, 2, *rc)
-
-	err = ExitCode(42)
-	done, rc = interceptWrite(&os.Stderr), resetExit()
-	cfg.Run(noArgs...)
-	assert.Equal(t, "", done())
-	assert.Equal(t, 42, *rc)
+
+	var b bytes.Buffer
+	assert.Equal(t, 0, main.Execute(nil, &b, split("c1")))
+	assert.Empty(t, b.String())
+
+	err = ExitCode(3)
+	assert.Equal(t, 3, main.Execute(nil, &b, split("c1")))
+	assert.Empty(t, b.String())
 
 	err = errors.New("fail")
-	done, rc = interceptWrite(&os.Stderr), resetExit()
-	cfg.Run(noArgs...)
-	assert.Equal(t, "Error: fail\n", done())
-	assert.Equal(t, 1, *rc)
+	assert.Equal(t, 1, main.Execute(nil, &b, split("c1")))
+	assert.Equal(t, "Error: fail\n", b.String())
+
+	b.Reset()
+	err = &exitErr{"rich", 4}
+	assert.Equal(t, 4, main.Execute(nil, &b, split("c1")))
+	assert.Equal(t, "Error: rich\n", b.String())
+
+	b.Reset()
+	err = fmt.Errorf("wrapped: %w", &exitErr{"rich", 5})
+	assert.Equal(t, 5, main.Execute(nil, &b, split("c1")))
+	assert.Equal(t, "Error: wrapped\n  caused by: rich\n", b.String())
+
+	b.Reset()
+	err = fmt.Errorf("wrapped: %w", ExitCode(6))
+	assert.Equal(t, 6, main.Execute(nil, &b, split("c1")))
+	assert.Equal(t, "Error: wrapped\n  caused by: exit code 6\n", b.String())
+
+	b.Reset()
+	err = &exitErr{"zero", 0}
+	assert.Equal(t, 1, main.Execute(nil, &b, split("c1")))
+	assert.Equal(t, "Error: zero\n", b.String())
+
+	b.Reset()
+	assert.Equal(t, 2, main.Execute(nil, &b, split("c1 x")))
+	assert.Equal(t, "Error: command does not accept any arguments\n"+
+		"Usage: bin c1\n       bin c1 help\n", b.String())
+
+	b.Reset()
+	assert.Equal(t, 0, main.Execute(nil, &b, split("c1 help")))
+	assert.Equal(t, "Usage: bin c1\n       bin c1 help\n\n", b.String())
+
+	b.Reset()
+	assert.Equal(t, 2, main.Execute(nil, &b, nil))
+	assert.True(t, strings.HasPrefix(b.String(), "Specify command:\n  c1\n  c2\n"))
+
+	b.Reset()
+	assert.Equal(t, 0, main.Execute(nil, &b, split("old")))
+	assert.Equal(t, "Warning: \"old\" is deprecated, use \"c3\" instead\n", b.String())
+
+	b.Reset()
+	assert.Equal(t, 2, main.Execute(nil, &b, split("c2 help")))
+	assert.Contains(t, b.String(), "panic: help failed\n")
+	assert.Equal(t, -1, *rc)
 }
 
-func TestNilCmd(t *testing.T) {
-	defer func() { Exit = os.Exit }()
+func TestHooks(t *testing.T) {
+	var calls []string
+	hooks := func(c *Cfg, abort bool) {
+		name := Name(c)
+		c.Before = func(c *Cfg, _ Cmd, args []string) error {
+			calls = append(calls, "before "+name+" "+Name(c)+" "+strings.Join(args, ","))
+			if abort {
+				return errors.New("abort " + name)
+			}
+			return nil
+		}
+		c.After = func(c *Cfg, _ Cmd, _ []string, err error) error {
+			calls = append(calls, fmt.Sprintf("after %s %v", name, err))
+			if err != nil {
+				err = fmt.Errorf("%s: %v", name, err)
+			}
+			return err
+		}
+	}
+	var err error
 	var main Cfg
+	grp := main.Add(&Cfg{Name: "grp"})
+	c1 := grp.Add(&Cfg{Name: "c1", MaxArgs: -1, New: newTestCmd(func(args []string) error {
+		calls = append(calls, "main")
+		return err
+	})})
+	hooks(&main, false)
+	grp.Before = func(*Cfg, Cmd, []string) error { calls = append(calls, "before grp"); return nil }
+	hooks(c1, false)
+
+	var b bytes.Buffer
+	assert.Equal(t, 0, main.Execute(nil, &b, split("grp c1 a b")))
+	assert.Equal(t, []string{
+		"before  c1 a,b",
+		"before grp",
+		"before c1 c1 a,b",
+		"main",
+		"after c1 <nil>",
+		"after  <nil>",
+	}, calls)
 
-	done, rc := interceptWrite(&os.Stderr), resetExit()
-	New(&main).Main(nil)
-	assert.Equal(t, "Command not implemented\n", done())
-	assert.Equal(t, 2, *rc)
-
-	main.Add(&Cfg{Name: "c1"})
-	main.Add(&Cfg{Name: "c2", Summary: "Command 2"})
-	done, rc = interceptWrite(&os.Stderr), resetExit()
-	New(&main).Main(nil)
-	assert.Equal(t, Dedent(`
-		Specify command:
-		  c1
-		  c2  Command 2
-	`)[1:], done())
-	assert.Equal(t, 2, *rc)
+	calls, err = nil, errors.New("fail")
+	assert.Equal(t, 1, main.Execute(nil, &b, split("grp c1")))
+	assert.Equal(t, "Error: : c1: fail\n", b.String())
+	assert.Equal(t, []string{
+		"before  c1 ",
+		"before grp",
+		"before c1 c1 ",
+		"main",
+		"after c1 fail",
+		"after  c1: fail",
+	}, calls)
+
+	b.Reset()
+	calls = nil
+	hooks(c1, true)
+	assert.Equal(t, 1, main.Execute(nil, &b, split("grp c1")))
+	assert.Equal(t, "Error: : abort c1\n", b.String())
+	assert.Equal(t, []string{
+		"before  c1 ",
+		"before grp",
+		"before c1 c1 ",
+		"after  abort c1",
+	}, calls)
+
+	// Hooks are not called for help or usage errors
+	calls = nil
+	main.Execute(nil, &b, split("grp c1 help"))
+	main.Execute(nil, &b, split("grp c1 -x"))
+	assert.Empty(t, calls)
 }
 
+type exitErr struct {
+	msg  string
+	code int
+}
+
+func (e *exitErr) Error() string { return e.msg }
+func (e *exitErr) ExitCode() int { return e.code }
+
+type panicHelpCmd struct{}
+
+func (*panicHelpCmd) Main([]string) error { return nil }
+func (*panicHelpCmd) Help(*Writer)        { panic("help failed") }
+
 func split(s string) []string {
 	if s == "" {
 		return nil
@@ -180,3 +308,10 @@ func resetExit() *int {
 	}
 	return &rc
 }
+
+func interceptWarn() (warn *[]string, restore func()) {
+	orig := Warn
+	warn = new([]string)
+	Warn = func(msg string) { *warn = append(*warn, msg) }
+	return warn, func() { Warn = orig }
+}
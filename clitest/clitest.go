@@ -0,0 +1,186 @@
+// Package clitest provides tools for testing CLI programs in-process.
+package clitest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mxk/go-cli"
+)
+
+// Update causes golden file helpers to write new golden files instead of
+// comparing them. It is set by the CLITEST_UPDATE environment variable.
+var Update = os.Getenv("CLITEST_UPDATE") != ""
+
+// Input specifies command execution parameters.
+type Input struct {
+	Args  []string          // Command-line arguments without the program name
+	Env   map[string]string // Environment variables to set during execution
+	Stdin string            // Standard input contents
+}
+
+// Result contains the result of command execution.
+type Result struct {
+	Code   int    // Exit code or -1 if cli.Exit was not called
+	Stdout string // Standard output contents
+	Stderr string // Standard error contents
+}
+
+// Run executes command tree c in-process with the specified arguments.
+func Run(c *cli.Cfg, args ...string) *Result {
+	return (&Input{Args: args}).Run(c)
+}
+
+// Run executes command tree c in-process via c.Run. It captures stdout and
+// stderr, intercepts calls to cli.Exit, and restores all cli package globals
+// and environment variables before returning. Crash reports are written to
+// stderr instead of files. Commands must not call cli.Exit from other
+// goroutines.
+func (in *Input) Run(c *cli.Cfg) (r *Result) {
+	r = &Result{Code: -1}
+	defer SaveGlobals()()
+	defer setEnv(in.Env)()
+	defer setStdin(in.Stdin)()
+	stdout, stderr := capture(&os.Stdout), capture(&os.Stderr)
+	defer func() { r.Stdout, r.Stderr = stdout(), stderr() }()
+	defer func() {
+		if p := recover(); p != nil {
+			if _, ok := p.(cli.ExitPanic); !ok {
+				panic(p)
+			}
+		}
+	}()
+	cli.CrashDir = func() string { return "" }
+	cli.Exit = func(code int) {
+		if r.Code < 0 {
+			r.Code = code
+		}
+		panic(cli.ExitPanic(code))
+	}
+	c.Run(append(make([]string, 0, len(in.Args)), in.Args...)...)
+	return r
+}
+
+// SaveGlobals saves the values of cli package globals, such as cli.Bin and
+// cli.Debug, and returns a function that restores them:
+//
+//	defer clitest.SaveGlobals()()
+//
+// It also disables user-defined aliases by clearing cli.AliasFile, so that
+// tests do not depend on the alias file of the current user.
+func SaveGlobals() (restore func()) {
+	bin, debug, debugSpec, debugFlag := cli.Bin, cli.Debug, cli.DebugSpec, cli.DebugFlag
+	exit, warn, reportError, crashDir := cli.Exit, cli.Warn, cli.ReportError, cli.CrashDir
+	responseFiles, aliasFile, enablePlugins := cli.ResponseFiles, cli.AliasFile, cli.EnablePlugins
+	verbosity, logTime, logOutput := cli.Verbosity, cli.LogTime, cli.LogOutput
+	cli.AliasFile = func() string { return "" }
+	return func() {
+		cli.Bin, cli.Debug, cli.DebugSpec, cli.DebugFlag = bin, debug, debugSpec, debugFlag
+		cli.Exit, cli.Warn, cli.ReportError, cli.CrashDir = exit, warn, reportError, crashDir
+		cli.ResponseFiles, cli.AliasFile, cli.EnablePlugins = responseFiles, aliasFile, enablePlugins
+		cli.Verbosity, cli.LogTime, cli.LogOutput = verbosity, logTime, logOutput
+	}
+}
+
+// Golden compares have with the contents of testdata/<name>.golden. If Update
+// is set, the file is written instead.
+func Golden(t testing.TB, name, have string) {
+	t.Helper()
+	file := filepath.Join("testdata", name+".golden")
+	if Update {
+		err := os.MkdirAll(filepath.Dir(file), 0o755)
+		if err == nil {
+			err = ioutil.WriteFile(file, []byte(have), 0o644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("%v (set CLITEST_UPDATE=1 to create)", err)
+	}
+	if have != string(want) {
+		t.Errorf("%s mismatch (set CLITEST_UPDATE=1 to update)\n"+
+			"--- have:\n%s\n--- want:\n%s", file, have, want)
+	}
+}
+
+// GoldenHelp compares the help output of c with a golden file. The cli.Bin
+// value is set to bin while generating help, and user-defined aliases are
+// disabled.
+func GoldenHelp(t testing.TB, name, bin string, c *cli.Cfg) {
+	t.Helper()
+	defer SaveGlobals()()
+	cli.Bin = bin
+	Golden(t, name, c.Help().String())
+}
+
+// setEnv sets environment variables and returns a function that restores their
+// original values.
+func setEnv(env map[string]string) (restore func()) {
+	type orig struct {
+		v  string
+		ok bool
+	}
+	saved := make(map[string]orig, len(env))
+	for k, v := range env {
+		o := orig{}
+		o.v, o.ok = os.LookupEnv(k)
+		saved[k] = o
+		os.Setenv(k, v)
+	}
+	return func() {
+		for k, o := range saved {
+			if o.ok {
+				os.Setenv(k, o.v)
+			} else {
+				os.Unsetenv(k)
+			}
+		}
+	}
+}
+
+// setStdin replaces os.Stdin with a pipe that returns s and returns a function
+// that restores the original value.
+func setStdin(s string) (restore func()) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		panic(err)
+	}
+	orig := os.Stdin
+	os.Stdin = r
+	go func() {
+		w.WriteString(s)
+		w.Close()
+	}()
+	return func() {
+		os.Stdin = orig
+		r.Close()
+	}
+}
+
+// capture replaces *f with a pipe and returns a function that restores the
+// original value and returns everything written to the pipe.
+func capture(f **os.File) (done func() string) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		panic(err)
+	}
+	orig := *f
+	*f = w
+	ch := make(chan string, 1)
+	go func() {
+		b, _ := ioutil.ReadAll(r)
+		r.Close()
+		ch <- string(b)
+	}()
+	return func() string {
+		*f = orig
+		w.Close()
+		return <-ch
+	}
+}
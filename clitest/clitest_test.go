@@ -0,0 +1,106 @@
+package clitest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/mxk/go-cli"
+	"github.com/stretchr/testify/assert"
+)
+
+type echoCmd struct {
+	Err bool `cli:"Return an error"`
+}
+
+func (cmd *echoCmd) Main(args []string) error {
+	fmt.Println(args)
+	fmt.Fprintln(os.Stderr, os.Getenv("CLITEST_VAR"))
+	if cmd.Err {
+		return errors.New("fail")
+	}
+	return nil
+}
+
+type catCmd struct{}
+
+func (*catCmd) Main([]string) error {
+	s := bufio.NewScanner(os.Stdin)
+	for s.Scan() {
+		fmt.Println(s.Text())
+	}
+	cli.Exit(3)
+	return nil
+}
+
+func newTree() *cli.Cfg {
+	var root cli.Cfg
+	root.Add(&cli.Cfg{
+		Name:    "echo",
+		Summary: "Print arguments",
+		MaxArgs: -1,
+		New:     func() cli.Cmd { return new(echoCmd) },
+	})
+	root.Add(&cli.Cfg{
+		Name:    "cat",
+		Summary: "Copy stdin to stdout",
+		New:     func() cli.Cmd { return new(catCmd) },
+	})
+	return &root
+}
+
+func TestRun(t *testing.T) {
+	defer SaveGlobals()()
+	cli.Bin = "bin"
+	exit := cli.Exit
+	root := newTree()
+
+	r := (&Input{
+		Args: []string{"echo", "a", "b"},
+		Env:  map[string]string{"CLITEST_VAR": "value"},
+	}).Run(root)
+	assert.Equal(t, &Result{Code: 0, Stdout: "[a b]\n", Stderr: "value\n"}, r)
+	_, ok := os.LookupEnv("CLITEST_VAR")
+	assert.False(t, ok)
+
+	r = Run(root, "echo", "-err")
+	assert.Equal(t, &Result{Code: 1, Stdout: "[]\n", Stderr: "\nError: fail\n"}, r)
+
+	r = (&Input{Args: []string{"cat"}, Stdin: "x\ny\n"}).Run(root)
+	assert.Equal(t, &Result{Code: 3, Stdout: "x\ny\n"}, r)
+
+	r = Run(root, "x")
+	assert.Equal(t, 2, r.Code)
+	assert.Contains(t, r.Stderr, `Error: unknown command "x"`)
+
+	r = Run(root)
+	assert.Equal(t, 2, r.Code)
+	assert.Contains(t, r.Stderr, "Specify command:")
+
+	r = Run(&cli.Cfg{New: func() cli.Cmd { return nil }})
+	assert.Equal(t, 2, r.Code)
+	assert.Contains(t, r.Stderr, "Error: internal error: runtime error: invalid memory address")
+	assert.Contains(t, r.Stderr, "goroutine ")
+	assert.Equal(t, reflect.ValueOf(exit).Pointer(), reflect.ValueOf(cli.Exit).Pointer())
+}
+
+func TestSaveGlobals(t *testing.T) {
+	bin, debug := cli.Bin, cli.Debug
+	aliasFile := reflect.ValueOf(cli.AliasFile).Pointer()
+	restore := SaveGlobals()
+	assert.Equal(t, "", cli.AliasFile())
+	cli.Bin, cli.Debug, cli.Exit = "x", !debug, nil
+	restore()
+	assert.Equal(t, aliasFile, reflect.ValueOf(cli.AliasFile).Pointer())
+	assert.Equal(t, bin, cli.Bin)
+	assert.Equal(t, debug, cli.Debug)
+	assert.NotNil(t, cli.Exit)
+}
+
+func TestGoldenHelp(t *testing.T) {
+	GoldenHelp(t, "help", "bin", newTree())
+	Golden(t, "echo", Run(newTree(), "echo", "a").Stdout)
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mxk/go-cli/build"
+)
+
+// CrashDir returns the directory where crash reports are written when a
+// command panics, or an empty string to disable crash reports. By default,
+// reports are written to "<Bin>/crash" in the user cache directory.
+var CrashDir = func() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, Bin, "crash")
+}
+
+// secretFlag matches flag names whose values are redacted in crash reports.
+var secretFlag = regexp.MustCompile(`(?i)pass|secret|token|key|auth|cred|cookie`)
+
+// runSafe calls c.run and recovers any panic other than ExitPanic. A recovered
+// panic is reported to out, a crash report for command line argv is written to
+// CrashDir, and the returned error is ExitCode(2).
+func (c *Cfg) runSafe(cmd Cmd, args, argv []string) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			if _, ok := p.(ExitPanic); ok {
+				panic(p)
+			}
+			crash(errWriter(), argv, p, debug.Stack())
+			err = ExitCode(2)
+		}
+	}()
+	return c.run(cmd, args)
+}
+
+// crash reports a panic to out and writes a crash report.
+func crash(out io.Writer, argv []string, p interface{}, stack []byte) {
+	fmt.Fprintf(out, "Error: internal error: %v\n", p)
+	report := crashReport(argv, p, stack)
+	if dir := CrashDir(); dir != "" {
+		name, err := writeCrashReport(dir, report)
+		if err == nil {
+			fmt.Fprintf(out, "A crash report was written to: %s\n", name)
+			fmt.Fprintf(out, "Please include it when reporting this problem.\n")
+			if !Debug {
+				return
+			}
+		} else {
+			fmt.Fprintf(out, "Failed to write crash report: %v\n", err)
+		}
+	}
+	fmt.Fprintf(out, "\n%s", report)
+}
+
+// crashReport returns the contents of a crash report. The command line is
+// recorded as Bin followed by argv.
+func crashReport(argv []string, p interface{}, stack []byte) string {
+	var b strings.Builder
+	b.Grow(4096 + len(stack))
+	fmt.Fprintf(&b, "%s crash report (%s)\n\n", Bin, time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "panic: %v\n\n", p)
+	argv = append([]string{Bin}, argv...)
+	fmt.Fprintf(&b, "args: %q\n\n", redactArgs(argv))
+	b.WriteString(build.String())
+	env := os.Environ()
+	for i, kv := range env {
+		if j := strings.IndexByte(kv, '='); j >= 0 {
+			env[i] = kv[:j]
+		}
+	}
+	sort.Strings(env)
+	fmt.Fprintf(&b, "\nenv: %s\n\n", strings.Join(env, " "))
+	b.Write(stack)
+	return b.String()
+}
+
+// writeCrashReport writes a crash report to a new file in dir and returns the
+// file name.
+func writeCrashReport(dir, report string) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	f, err := ioutil.TempFile(dir, time.Now().Format("crash-20060102-150405-*.txt"))
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString(report)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// redactArgs returns a copy of args with the values of secret-looking flags and
+// URL passwords replaced with "REDACTED".
+func redactArgs(args []string) []string {
+	const redacted = "REDACTED"
+	out := make([]string, len(args))
+	next, flags := false, true
+	for i, arg := range args {
+		if out[i] = arg; next {
+			out[i], next = redacted, false
+			continue
+		}
+		if arg == "--" {
+			flags = false
+		} else if name := strings.TrimLeft(arg, "-"); flags && len(name) < len(arg) {
+			if j := strings.IndexByte(name, '='); j >= 0 {
+				if secretFlag.MatchString(name[:j]) {
+					out[i] = arg[:len(arg)-len(name)+j+1] + redacted
+				}
+			} else {
+				next = secretFlag.MatchString(name)
+			}
+			continue
+		}
+		if u, err := url.Parse(arg); err == nil && u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				u.User = url.UserPassword(u.User.Username(), redacted)
+				out[i] = u.String()
+			}
+		}
+	}
+	return out
+}
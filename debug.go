@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DebugFlag is the name of a global flag that sets Debug and DebugSpec. It is
+// accepted before any command name and as an option of any command that does
+// not define a flag with the same name. "-debug" enables debugging without a
+// spec, "-debug=false" disables it, and "-debug=<spec>" enables debugging and
+// appends spec to DebugSpec. The flag is disabled by default. When enabled, it
+// is listed in the options of each command that accepts it.
+var DebugFlag = ""
+
+// debugCache contains the parsed DebugSpec.
+var debugCache struct {
+	sync.Mutex
+	spec string
+	m    map[string]string
+}
+
+// ParseDebugSpec parses a GODEBUG-style spec of comma-separated "name[=value]"
+// entries, such as "net=2,db,sql=trace". Names without a value map to an empty
+// string, and later entries override earlier ones. Empty entries are ignored.
+// If an entry is invalid, the returned map contains all valid entries.
+func ParseDebugSpec(spec string) (map[string]string, error) {
+	var err error
+	m := make(map[string]string)
+	for _, e := range strings.Split(spec, ",") {
+		if e = strings.TrimSpace(e); e == "" {
+			continue
+		}
+		name, val := e, ""
+		if i := strings.IndexByte(e, '='); i >= 0 {
+			name, val = strings.TrimSpace(e[:i]), strings.TrimSpace(e[i+1:])
+		}
+		if name == "" {
+			if err == nil {
+				err = fmt.Errorf("cli: invalid debug spec entry %q", e)
+			}
+			continue
+		}
+		m[name] = val
+	}
+	return m, err
+}
+
+// DebugLevel returns the debug level of the named subsystem. It returns 0 if
+// Debug is not set. Otherwise, if DebugSpec is empty, the level of every
+// subsystem is 1. If DebugSpec is not empty, the level is determined by the
+// subsystem entry, or the "*" entry if there is no entry for name. Entries
+// without a value and those with a non-numeric value have level 1, bool values
+// have level 0 or 1, and numeric values have the specified level. Subsystems
+// without an entry have level 0.
+func DebugLevel(name string) int {
+	if !Debug {
+		return 0
+	}
+	if DebugSpec == "" {
+		return 1
+	}
+	v, ok := DebugValue(name)
+	if !ok {
+		return 0
+	}
+	if n, err := strconv.Atoi(v); err == nil {
+		return n
+	}
+	if b, err := strconv.ParseBool(v); err == nil && !b {
+		return 0
+	}
+	return 1
+}
+
+// DebugValue returns the DebugSpec value of the named subsystem, falling back
+// to the "*" entry. It returns false if Debug is not set or the subsystem does
+// not have an entry.
+func DebugValue(name string) (string, bool) {
+	if !Debug {
+		return "", false
+	}
+	debugCache.Lock()
+	defer debugCache.Unlock()
+	if debugCache.m == nil || debugCache.spec != DebugSpec {
+		debugCache.spec = DebugSpec
+		debugCache.m, _ = ParseDebugSpec(DebugSpec)
+	}
+	v, ok := debugCache.m[name]
+	if !ok {
+		v, ok = debugCache.m["*"]
+	}
+	return v, ok
+}
+
+// setDebug sets Debug and DebugSpec from a DebugFlag value.
+func setDebug(v string) error {
+	if b, err := strconv.ParseBool(v); err == nil {
+		if Debug = b; !b {
+			DebugSpec = ""
+		}
+		return nil
+	}
+	if _, err := ParseDebugSpec(v); err != nil {
+		return err
+	}
+	if DebugSpec != "" {
+		v = DebugSpec + "," + v
+	}
+	DebugSpec, Debug = v, true
+	return nil
+}
+
+// debugFlagValue returns the value of arg if it is a DebugFlag argument.
+func debugFlagValue(arg string) (string, bool) {
+	if DebugFlag == "" || len(arg) < 2 || arg[0] != '-' {
+		return "", false
+	}
+	name := arg[1:]
+	if name[0] == '-' {
+		name = name[1:]
+	}
+	if !strings.HasPrefix(name, DebugFlag) {
+		return "", false
+	}
+	if name = name[len(DebugFlag):]; name == "" {
+		return "true", true
+	} else if name[0] == '=' {
+		return name[1:], true
+	}
+	return "", false
+}
+
+// debugHelpFlag returns DebugFlag information for help output.
+func debugHelpFlag() *Flag {
+	return &Flag{Flag: &flag.Flag{
+		Name:  DebugFlag,
+		Usage: "Enable debugging or set the debug spec via -" + DebugFlag + "=<spec>",
+		Value: debugValue{},
+	}}
+}
+
+// debugValue implements flag.Value for DebugFlag.
+type debugValue struct{}
+
+func (debugValue) String() string     { return "" }
+func (debugValue) Set(v string) error { return setDebug(v) }
+func (debugValue) IsBoolFlag() bool   { return true }
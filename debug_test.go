@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDebugSpec(t *testing.T) {
+	m, err := ParseDebugSpec("")
+	require.NoError(t, err)
+	assert.Empty(t, m)
+
+	m, err = ParseDebugSpec(" net=2, db ,,sql=trace,net=3,x=")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"net": "3", "db": "", "sql": "trace", "x": ""}, m)
+
+	m, err = ParseDebugSpec("a,=1,b=2")
+	assert.EqualError(t, err, `cli: invalid debug spec entry "=1"`)
+	assert.Equal(t, map[string]string{"a": "", "b": "2"}, m)
+}
+
+func TestDebugLevel(t *testing.T) {
+	defer func(d bool, s string) { Debug, DebugSpec = d, s }(Debug, DebugSpec)
+	Debug, DebugSpec = false, "net=2"
+	assert.Equal(t, 0, DebugLevel("net"))
+	_, ok := DebugValue("net")
+	assert.False(t, ok)
+
+	Debug, DebugSpec = true, ""
+	assert.Equal(t, 1, DebugLevel("net"))
+	_, ok = DebugValue("net")
+	assert.False(t, ok)
+
+	DebugSpec = "net=2,db,sql=trace,tls=false,-"
+	tests := []*struct {
+		name  string
+		level int
+		val   string
+	}{
+		{"net", 2, "2"},
+		{"db", 1, ""},
+		{"sql", 1, "trace"},
+		{"tls", 0, "false"},
+		{"x", 0, ""},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.level, DebugLevel(tc.name), "%s", tc.name)
+		v, _ := DebugValue(tc.name)
+		assert.Equal(t, tc.val, v, "%s", tc.name)
+	}
+
+	DebugSpec = "*=3,db=0"
+	assert.Equal(t, 3, DebugLevel("net"))
+	assert.Equal(t, 0, DebugLevel("db"))
+}
+
+func TestDebugFlag(t *testing.T) {
+	defer func(d bool, s, f string) { Debug, DebugSpec, DebugFlag = d, s, f }(Debug, DebugSpec, DebugFlag)
+	var main Cfg
+	cmd := main.Add(&Cfg{Name: "cmd", New: newTestCmd(nil), MaxArgs: -1})
+	own := main.Add(&Cfg{Name: "own", New: func() Cmd { return &debugCmd{} }})
+	tests := []*struct {
+		args  string
+		debug bool
+		spec  string
+		rest  []string
+		err   string
+	}{
+		{args: "cmd -debug x", debug: true, rest: []string{"x"}},
+		{args: "-debug cmd", debug: true, rest: []string{}},
+		{args: "--debug=net=2 cmd -debug=db", debug: true, spec: "net=2,db", rest: []string{}},
+		{args: "-debug=net -debug=false cmd", rest: []string{}},
+		{args: "cmd x -debug", rest: []string{"x", "-debug"}},
+		{args: "-debugx cmd", err: `unknown command "-debugx"`},
+		{args: "-debug==1 cmd", err: `cli: invalid debug spec entry "=1"`},
+		{args: "own -debug", rest: []string{}},
+	}
+	DebugFlag = "debug"
+	for _, tc := range tests {
+		Debug, DebugSpec = false, ""
+		_, cmd, args, err := main.Parse(split(tc.args))
+		if tc.err != "" {
+			assert.EqualError(t, err, tc.err, "%s", tc.args)
+			continue
+		}
+		require.NoError(t, err, "%s", tc.args)
+		assert.Equal(t, tc.debug, Debug, "%s", tc.args)
+		assert.Equal(t, tc.spec, DebugSpec, "%s", tc.args)
+		assert.Equal(t, tc.rest, args, "%s", tc.args)
+		if c, ok := cmd.(*debugCmd); ok {
+			assert.True(t, c.Debug)
+		}
+	}
+
+	// Help lists the flag unless the command defines its own
+	Bin = "bin"
+	assert.Equal(t, Dedent(`
+		Usage: bin cmd
+		       bin cmd help
+
+		Options:
+		  -debug
+		    	Enable debugging or set the debug spec via -debug=<spec>
+
+	`)[1:], cmd.Help().String())
+	assert.NotContains(t, own.Help().String(), "debug spec")
+
+	DebugFlag = ""
+	assert.NotContains(t, cmd.Help().String(), "Options")
+	_, _, _, err := main.Parse(split("-debug cmd"))
+	assert.EqualError(t, err, `unknown command "-debug"`)
+	_, _, _, err = main.Parse(split("cmd -debug"))
+	assert.Error(t, err)
+}
+
+type debugCmd struct {
+	Debug bool `cli:"Command-specific debug flag"`
+}
+
+func (*debugCmd) Main([]string) error { return nil }
@@ -16,19 +16,210 @@ var ErrHelp = flag.ErrHelp
 
 // NewFlagSet defines flags using field tags in s, which should be a struct
 // pointer.
-func NewFlagSet(s interface{}) *flag.FlagSet {
-	fs := &flag.FlagSet{Usage: func() {}}
+func NewFlagSet(s interface{}) *flag.FlagSet { return newFlagSet(s).FlagSet }
+
+// Flag contains flag information that is specified in struct field tags, but
+// is not available from flag.Flag.
+type Flag struct {
+	*flag.Flag
+	Deprecated string   // Replacement hint for deprecated flags
+	Hidden     bool     // Omit from help and auto-completion
+	Negatable  bool     // Accept -no-<name> form to set the flag to false
+	Alias      []string // Alternate names, excluding deprecated ones
+
+	def string   // Non-zero default value formatted for help output
+	old []string // Deprecated aliases
+}
+
+// Flags returns all flags defined by field tags in s, sorted by name.
+func Flags(s interface{}) []*Flag { return newFlagSet(s).flags }
+
+// flagSet is a flag.FlagSet with additional information about each flag.
+type flagSet struct {
+	*flag.FlagSet
+	flags []*Flag
+}
+
+// newFlagSet returns a new flagSet for struct pointer s.
+func newFlagSet(s interface{}) *flagSet {
+	fs := &flagSet{FlagSet: &flag.FlagSet{Usage: func() {}}}
 	fs.SetOutput(ioutil.Discard)
 	if v := reflect.ValueOf(s); v.Kind() == reflect.Ptr {
 		if v = v.Elem(); v.Kind() == reflect.Struct {
-			defineFlags(fs, v)
+			fs.define(v)
+		}
+	}
+	for _, f := range fs.flags {
+		if f.Negatable && fs.Lookup("no-"+f.Name) != nil {
+			f.Negatable = false
 		}
 	}
+	sort.Slice(fs.flags, func(i, j int) bool {
+		return fs.flags[i].Name < fs.flags[j].Name
+	})
 	return fs
 }
 
-// defineFlags configures fs using the fields of struct v.
-func defineFlags(fs *flag.FlagSet, v reflect.Value) {
+// parse parses flag arguments and reports the use of deprecated flags.
+func (fs *flagSet) parse(args []string) error {
+	args, neg, err := fs.expandNegated(fs.expandCounts(args))
+	if err != nil {
+		return err
+	}
+	if err = fs.Parse(args); err != nil {
+		return err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		name := f.Name
+		if neg[name] {
+			name = "no-" + name
+		}
+		if fl := fs.lookup(f.Name); fl == nil {
+			return
+		} else if fl.Deprecated != "" {
+			dep := fl.Deprecated
+			if neg[f.Name] {
+				i := len(dep) - len(strings.TrimLeft(dep, "-"))
+				dep = dep[:i] + "no-" + dep[i:]
+			}
+			Warn(fmt.Sprintf("%q is deprecated, use %q instead", "-"+name, dep))
+		} else if fl.isOld(f.Name) {
+			use := fl.Name
+			if neg[f.Name] {
+				use = "no-" + use
+			}
+			Warn(fmt.Sprintf("%q is deprecated, use %q instead", "-"+name, "-"+use))
+		}
+	})
+	return nil
+}
+
+// expandCounts replaces bundled single-letter Count flags (e.g. "-vvv") with
+// separate flags ("-v -v -v") unless the bundle itself is a defined flag.
+func (fs *flagSet) expandCounts(args []string) []string {
+	var out []string
+	done := 0
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if len(arg) < 2 || arg[0] != '-' || arg == "--" {
+			break
+		}
+		name := strings.TrimPrefix(arg[1:], "-")
+		if f := fs.Lookup(name); f != nil {
+			if b, ok := f.Value.(boolFlag); !ok || !b.IsBoolFlag() {
+				i++ // Skip flag value
+			}
+			continue
+		}
+		f := fs.Lookup(name[:1])
+		if f == nil || strings.Count(name, name[:1]) != len(name) {
+			continue
+		}
+		if _, ok := f.Value.(*Count); !ok {
+			continue
+		}
+		if out == nil {
+			out = make([]string, 0, len(args)+len(name))
+		}
+		out = append(out, args[done:i]...)
+		for range name {
+			out = append(out, "-"+name[:1])
+		}
+		done = i + 1
+	}
+	if out == nil {
+		return args
+	}
+	return append(out, args[done:]...)
+}
+
+// expandNegated replaces the negated form of negatable flags (e.g. "-no-cache"
+// or "-no-cache=false") with the regular form ("-cache=false" or "-cache=true")
+// and returns the names of the negated flags. Negated forms are not defined in
+// the FlagSet to keep them out of VisitAll and PrintDefaults.
+func (fs *flagSet) expandNegated(args []string) ([]string, map[string]bool, error) {
+	var out []string
+	var neg map[string]bool
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if len(arg) < 2 || arg[0] != '-' || arg == "--" {
+			break
+		}
+		dash := "-"
+		if arg[1] == '-' {
+			dash = "--"
+		}
+		name, val := arg[len(dash):], ""
+		j := strings.IndexByte(name, '=')
+		if j >= 0 {
+			name, val = name[:j], name[j+1:]
+		}
+		if f := fs.Lookup(name); f != nil {
+			if b, ok := f.Value.(boolFlag); j < 0 && (!ok || !b.IsBoolFlag()) {
+				i++ // Skip flag value
+			}
+			continue
+		}
+		f := fs.lookup(name)
+		if f == nil || f.Name == name {
+			continue
+		}
+		b := true
+		if j >= 0 {
+			var err error
+			if b, err = strconv.ParseBool(val); err != nil {
+				return nil, nil, fmt.Errorf("invalid boolean value %q for -%s: %v", val, name, err)
+			}
+		}
+		if out == nil {
+			out = append(make([]string, 0, len(args)), args...)
+			neg = make(map[string]bool)
+		}
+		name = strings.TrimPrefix(name, "no-")
+		out[i] = dash + name + "=" + strconv.FormatBool(!b)
+		neg[name] = true
+	}
+	if out == nil {
+		return args, nil, nil
+	}
+	return out, neg, nil
+}
+
+// lookup returns information for the named flag, including aliases and the
+// negated form of negatable flags.
+func (fs *flagSet) lookup(name string) *Flag {
+	for _, f := range fs.flags {
+		if f.Name == name || f.isOld(name) {
+			return f
+		}
+		for _, alias := range f.Alias {
+			if alias == name {
+				return f
+			}
+		}
+	}
+	if neg := strings.TrimPrefix(name, "no-"); neg != name {
+		for _, f := range fs.flags {
+			if f.Negatable && (f.Name == neg || f.isOld(neg)) {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// isOld returns true if name is a deprecated alias of f.
+func (f *Flag) isOld(name string) bool {
+	for _, old := range f.old {
+		if old == name {
+			return true
+		}
+	}
+	return false
+}
+
+// define configures fs using the fields of struct v.
+func (fs *flagSet) define(v reflect.Value) {
 	t := v.Type()
 	n := v.NumField()
 	for i := 0; i < n; i++ {
@@ -40,25 +231,17 @@ func defineFlags(fs *flag.FlagSet, v reflect.Value) {
 				fv = fv.Elem()
 			}
 			if fv.Kind() == reflect.Struct && fv.CanInterface() {
-				defineFlags(fs, fv)
+				fs.define(fv)
 			}
 			continue
 		}
-		j := strings.IndexByte(tag, ',')
-		if sp := strings.IndexByte(tag, ' '); 0 <= sp && sp <= j+1 {
-			j = -1
-		}
-		name, usage := "", convQuote(tag[j+1:])
-		if j > 0 {
-			name = tag[:j]
-		} else {
-			name = flagName(f.Name)
-		}
+		ft := parseTag(f.Name, tag)
+		name, usage := ft.name, ft.usage
 		switch p := v.Field(i).Addr().Interface().(type) {
 		case *bool:
 			fs.BoolVar(p, name, *p, usage)
 		case *time.Duration:
-			fs.DurationVar(p, name, *p, usage)
+			fs.Var((*Duration)(p), name, usage)
 		case *float64:
 			fs.Float64Var(p, name, *p, usage)
 		case *int:
@@ -89,14 +272,148 @@ func defineFlags(fs *flag.FlagSet, v reflect.Value) {
 			fs.Var(uintPtr{p}, name, usage)
 		case **uint64:
 			fs.Var(u64Ptr{p}, name, usage)
-		case *[]string:
-			fs.Var(strSlice{p}, name, usage)
-		case *map[string]string:
-			fs.Var(strMap{p}, name, usage)
 		default:
-			panic("cli: unsupported flag type: " + f.Type.String())
+			fs.Var(ft.value(v.Field(i)), name, usage)
+		}
+		fs.add(v.Field(i), &ft)
+	}
+}
+
+// add records information for the most recently defined flag.
+func (fs *flagSet) add(v reflect.Value, ft *flagTag) {
+	f := &Flag{Flag: fs.Lookup(ft.name)}
+	for _, alias := range ft.alias {
+		if alias != "" && alias[0] == deprecatedAlias {
+			alias = alias[1:]
+			f.old = append(f.old, alias)
+		} else {
+			f.Alias = append(f.Alias, alias)
+		}
+		if alias == "" {
+			panic("cli: missing flag alias: " + ft.name)
+		}
+		fs.Var(f.Value, alias, f.Usage)
+	}
+	if dep, ok := ft.opts["deprecated"]; ok {
+		if dep == "" {
+			panic("cli: missing replacement for deprecated flag: " + ft.name)
+		}
+		f.Deprecated = dep
+	}
+	_, f.Hidden = ft.opts["hidden"]
+	switch v.Interface().(type) {
+	case bool, *bool:
+		f.Negatable = !strings.HasPrefix(f.Name, "no-")
+	}
+	if !v.IsZero() {
+		if f.def = f.DefValue; v.Kind() == reflect.String {
+			f.def = strconv.Quote(f.def)
+		}
+	}
+	fs.flags = append(fs.flags, f)
+}
+
+// value returns a flag.Value for types that are handled via reflection.
+func (ft *flagTag) value(v reflect.Value) flag.Value {
+	t := v.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if textParser(t) != nil {
+		return &anyValue{v, ft.parser(t)}
+	}
+	_, split := ft.opts["split"]
+	switch v.Kind() {
+	case reflect.Slice:
+		if sv := newSliceValue(v, ft.parser, split); sv != nil {
+			return sv
+		}
+	case reflect.Map:
+		if mv := newMapValue(v, ft.parser, split, ft.opts["dup"]); mv != nil {
+			return mv
 		}
 	}
+	panic("cli: unsupported flag type: " + v.Type().String())
+}
+
+// parser returns a parseFunc for type t that applies any relevant tag options.
+func (ft *flagTag) parser(t reflect.Type) parseFunc {
+	switch t {
+	case urlType:
+		return parseURL(ft.opts["scheme"])
+	case timeType:
+		var loc *time.Location
+		if tz := ft.opts["tz"]; tz != "" {
+			var err error
+			if loc, err = time.LoadLocation(tz); err != nil {
+				panic("cli: invalid time zone for flag " + ft.name + ": " + err.Error())
+			}
+		}
+		return parseTime(ft.opts["layout"], loc)
+	}
+	return parser(t)
+}
+
+// flagTag is a parsed "cli" field tag. The tag format is
+// "[name[|alias...],[option[=value],...]]usage". The names and options may not
+// contain spaces or commas, and there is no quoting, so a time layout option
+// such as "layout=Jan 2, 2006" cannot be specified. An unrecognized option is
+// treated as the start of usage. As with command names, a '~' prefix marks a
+// deprecated alias.
+type flagTag struct {
+	name  string
+	alias []string
+	usage string
+	opts  map[string]string
+}
+
+// flagOpts contains the names of supported flag tag options.
+var flagOpts = map[string]bool{
+	"deprecated": true, // deprecated=<replacement>
+	"hidden":     true,
+	"split":      true, // Split slice and map values on commas
+	"dup":        true, // dup=(overwrite|append|error) for map flags
+	"scheme":     true, // scheme=<scheme>[|<scheme>...] for URL flags
+	"layout":     true, // layout=<time layout> without commas or spaces
+	"tz":         true, // tz=<location> for time flags
+}
+
+// parseTag parses the "cli" tag of the specified struct field.
+func parseTag(field, tag string) (ft flagTag) {
+	j := strings.IndexByte(tag, ',')
+	if sp := strings.IndexByte(tag, ' '); 0 <= sp && sp <= j+1 {
+		j = -1
+	}
+	if j > 0 {
+		ft.name = tag[:j]
+		if i := strings.IndexByte(ft.name, nameSep); i >= 0 {
+			ft.name, ft.alias = ft.name[:i], strings.Split(ft.name[i+1:], string(nameSep))
+		}
+	}
+	if ft.name == "" {
+		ft.name = flagName(field)
+	}
+	for tag = tag[j+1:]; j >= 0; tag = tag[j+1:] {
+		if j = strings.IndexByte(tag, ','); j <= 0 {
+			break
+		}
+		opt, val := tag[:j], ""
+		if strings.IndexByte(opt, ' ') >= 0 || strings.HasPrefix(tag[j+1:], " ") {
+			break
+		}
+		if i := strings.IndexByte(opt, '='); i >= 0 {
+			opt, val = opt[:i], opt[i+1:]
+		}
+		if !flagOpts[opt] {
+			break
+		}
+		if ft.opts == nil {
+			ft.opts = make(map[string]string)
+		}
+		ft.opts[opt] = val
+	}
+	ft.usage = convQuote(tag)
+	return
 }
 
 // convQuote converts "{name}" to "`name`" in usage strings. This format is
@@ -184,17 +501,19 @@ func (p durPtr) String() string {
 	if p.v != nil && *p.v != nil {
 		v = **p.v
 	}
-	return v.String()
+	return Duration(v).String()
 }
 
 func (p durPtr) Set(s string) error {
-	v, err := time.ParseDuration(s)
+	v, err := parseDuration(s)
 	*p.v = &v
 	return err
 }
 
 func (p durPtr) Get() interface{} { return *p.v }
 
+func (durPtr) typeName() string { return "duration" }
+
 // f64Ptr implements flag.Value for *float64 flags.
 type f64Ptr struct{ v **float64 }
 
@@ -310,64 +629,39 @@ func (p u64Ptr) Set(s string) error {
 
 func (p u64Ptr) Get() interface{} { return *p.v }
 
-// strSlice implements flag.Value for []string flags.
-type strSlice struct{ v *[]string }
-
-func (p strSlice) String() string {
-	var v []string
-	if p.v != nil {
-		v = *p.v
-	}
-	return fmt.Sprint(v)
-}
-
-func (p strSlice) Set(s string) error {
-	*p.v = append(*p.v, s)
-	return nil
+// boolFlag is copied from flag package to identify bool-style flags.
+type boolFlag interface {
+	flag.Value
+	IsBoolFlag() bool
 }
 
-func (p strSlice) Get() interface{} { return *p.v }
-
-// strMap implements flag.Value for map[string]string flags.
-type strMap struct{ v *map[string]string }
-
-func (p strMap) String() string {
-	var v map[string]string
-	if p.v != nil {
-		v = *p.v
-	}
-	var sb strings.Builder
-	sb.Grow(2 + 16*len(v))
-	sb.WriteByte('{')
-	if len(v) > 0 {
-		keys := make([]string, 0, len(v))
-		for k := range *p.v {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
-		for i, k := range keys {
-			if i > 0 {
-				sb.WriteByte(' ')
-			}
-			sb.WriteString(k)
-			sb.WriteByte('=')
-			sb.WriteString(v[k])
+// Count is an integer flag that is incremented each time the flag is
+// specified without a value (e.g. "-v -v" sets it to 2). Bundled single-letter
+// names (e.g. "-vv") are also accepted. An explicit value sets the count.
+type Count int
+
+// String implements flag.Value.
+func (c Count) String() string { return strconv.Itoa(int(c)) }
+
+// Set implements flag.Value.
+func (c *Count) Set(s string) error {
+	switch s {
+	case "true":
+		*c++
+	case "false":
+		*c = 0
+	default:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("cli: invalid count %q", s)
 		}
+		*c = Count(n)
 	}
-	sb.WriteByte('}')
-	return sb.String()
-}
-
-func (p strMap) Set(s string) error {
-	i := strings.IndexByte(s, '=')
-	if i < 0 {
-		return fmt.Errorf("cli: missing '=' in %q", s)
-	}
-	if *p.v == nil {
-		*p.v = make(map[string]string)
-	}
-	(*p.v)[s[:i]] = s[i+1:]
 	return nil
 }
 
-func (p strMap) Get() interface{} { return *p.v }
+// Get implements flag.Getter.
+func (c Count) Get() interface{} { return c }
+
+// IsBoolFlag allows the flag to be specified without a value.
+func (c Count) IsBoolFlag() bool { return true }
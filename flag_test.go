@@ -74,6 +74,68 @@ func TestNewFlagSet(t *testing.T) {
 	assert.Empty(t, usage)
 }
 
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want flagTag
+	}{
+		{"", flagTag{name: "field"}},
+		{"Usage", flagTag{name: "field", usage: "Usage"}},
+		{"n,Usage", flagTag{name: "n", usage: "Usage"}},
+		{"n|a|~b,Usage", flagTag{name: "n", alias: []string{"a", "~b"}, usage: "Usage"}},
+		{"|a,Usage", flagTag{name: "field", alias: []string{"a"}, usage: "Usage"}},
+		{",deprecated=x,", flagTag{name: "field", opts: map[string]string{"deprecated": "x"}}},
+		{"n,deprecated=x,Usage", flagTag{name: "n", usage: "Usage",
+			opts: map[string]string{"deprecated": "x"}}},
+		{"n,deprecated=x", flagTag{name: "n", usage: "deprecated=x"}},
+		{"n,hidden,deprecated=x,", flagTag{name: "n",
+			opts: map[string]string{"hidden": "", "deprecated": "x"}}},
+		{"n,unknown,Usage", flagTag{name: "n", usage: "unknown,Usage"}},
+		{"n,deprecated, Usage", flagTag{name: "n", usage: "deprecated, Usage"}},
+		{"Not, deprecated=x,", flagTag{name: "field", usage: "Not, deprecated=x,"}},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, parseTag("Field", tc.tag), "%q", tc.tag)
+	}
+}
+
+func TestDeprecatedFlag(t *testing.T) {
+	warn, restore := interceptWarn()
+	defer restore()
+
+	type Bad struct {
+		Old bool `cli:",deprecated=,"`
+	}
+	assert.PanicsWithValue(t, "cli: missing replacement for deprecated flag: old",
+		func() { NewFlagSet(new(Bad)) })
+
+	var v struct {
+		New string `cli:"Usage"`
+		Old string `cli:",deprecated=-new,Usage"`
+	}
+	fs := newFlagSet(&v)
+	require.Len(t, fs.flags, 2)
+	assert.Equal(t, "", fs.flags[0].Deprecated)
+	assert.Equal(t, "-new", fs.flags[1].Deprecated)
+
+	require.NoError(t, fs.parse(split("-new=a")))
+	assert.Empty(t, *warn)
+	require.NoError(t, fs.parse(split("-old=b")))
+	assert.Equal(t, []string{`"-old" is deprecated, use "-new" instead`}, *warn)
+	assert.Equal(t, "b", v.Old)
+}
+
+func TestHiddenFlag(t *testing.T) {
+	var v struct {
+		Debug bool `cli:",hidden,Usage"`
+	}
+	fs := newFlagSet(&v)
+	require.Len(t, fs.flags, 1)
+	assert.True(t, fs.flags[0].Hidden)
+	require.NoError(t, fs.parse(split("-debug")))
+	assert.True(t, v.Debug)
+}
+
 func TestFlagTypes(t *testing.T) {
 	ptr := func(x interface{}) interface{} {
 		v := reflect.ValueOf(x)
@@ -101,8 +163,19 @@ func TestFlagTypes(t *testing.T) {
 		UintPtr     *uint          `cli:""`
 		Uint64Ptr   *uint64        `cli:""`
 
-		Slice []string          `cli:""`
-		Map   map[string]string `cli:""`
+		Slice         []string                 `cli:""`
+		IntSlice      []int                    `cli:""`
+		Int64Slice    []int64                  `cli:""`
+		UintSlice     []uint                   `cli:""`
+		Float64Slice  []float64                `cli:""`
+		DurationSlice []time.Duration          `cli:",split,"`
+		BoolSlice     []bool                   `cli:""`
+		XYSlice       []XY                     `cli:",split,"`
+		Map           map[string]string        `cli:""`
+		IntMap        map[string]int           `cli:",split,"`
+		BoolMap       map[string]bool          `cli:""`
+		DurationMap   map[string]time.Duration `cli:""`
+		SliceMap      map[string][]string      `cli:""`
 	}
 	type test struct {
 		Name    string
@@ -131,8 +204,19 @@ func TestFlagTypes(t *testing.T) {
 		{"UintPtr", "0", "-uint-ptr=3", ptr(uint(3)), "3"},
 		{"Uint64Ptr", "0", "-uint64-ptr=4", ptr(uint64(4)), "4"},
 
-		{"Slice", "[]", "-slice=a -slice=b", []string{"a", "b"}, "[a b]"},
+		{"Slice", "", "-slice=a -slice=b", []string{"a", "b"}, "a,b"},
+		{"IntSlice", "", "-int-slice=1 -int-slice=0x10", []int{1, 16}, "1,16"},
+		{"Int64Slice", "", "-int64-slice=-1", []int64{-1}, "-1"},
+		{"UintSlice", "", "-uint-slice=1 -uint-slice=2", []uint{1, 2}, "1,2"},
+		{"Float64Slice", "", "-float64-slice=0.5", []float64{0.5}, "0.5"},
+		{"DurationSlice", "", "-duration-slice=1s,1m", []time.Duration{time.Second, time.Minute}, "1s,1m"},
+		{"BoolSlice", "", "-bool-slice -bool-slice=false", []bool{true, false}, "true,false"},
+		{"XYSlice", "", "-xy-slice=x,y -xy-slice=Y", []XY{'X', 'Y', 'Y'}, "X,Y,Y"},
 		{"Map", "{}", "-map=a=1 -map=b=2", map[string]string{"a": "1", "b": "2"}, "{a=1 b=2}"},
+		{"IntMap", "{}", "-int-map=a=1,b=2", map[string]int{"a": 1, "b": 2}, "{a=1 b=2}"},
+		{"BoolMap", "{}", "-bool-map=x=true", map[string]bool{"x": true}, "{x=true}"},
+		{"DurationMap", "{}", "-duration-map=x=1h -duration-map=y=1d", map[string]time.Duration{"x": time.Hour, "y": 24 * time.Hour}, "{x=1h y=1d}"},
+		{"SliceMap", "{}", "-slice-map=a=1 -slice-map=a=2,3", map[string][]string{"a": {"1", "2,3"}}, "{a=1 a=2,3}"},
 	}
 
 	var have, want T
@@ -244,3 +328,134 @@ func (v *XY) Set(s string) error {
 }
 
 func (v XY) Get() interface{} { return v }
+
+func TestNegatableFlag(t *testing.T) {
+	warn, restore := interceptWarn()
+	defer restore()
+
+	var v struct {
+		Cache  bool  `cli:""`
+		Color  *bool `cli:"color|~colour,"`
+		Old    bool  `cli:",deprecated=-cache,"`
+		Keep   bool  `cli:""`
+		NoKeep int   `cli:""`
+	}
+	v.Cache = true
+	fs := newFlagSet(&v)
+	var neg []string
+	for _, f := range fs.flags {
+		if f.Negatable {
+			neg = append(neg, f.Name)
+		}
+	}
+	assert.Equal(t, []string{"cache", "color", "old"}, neg)
+
+	require.NoError(t, fs.parse(split("-no-cache -no-color")))
+	assert.False(t, v.Cache)
+	require.NotNil(t, v.Color)
+	assert.False(t, *v.Color)
+	assert.Empty(t, *warn)
+
+	require.NoError(t, fs.parse(split("-no-color=false -no-old")))
+	assert.True(t, *v.Color)
+	assert.Equal(t, []string{`"-no-old" is deprecated, use "-no-cache" instead`}, *warn)
+	*warn = nil
+	require.NoError(t, newFlagSet(&v).parse(split("-no-colour")))
+	assert.False(t, *v.Color)
+	assert.Equal(t, []string{`"-no-colour" is deprecated, use "-no-color" instead`}, *warn)
+	assert.EqualError(t, fs.parse(split("-no-cache=x")),
+		`invalid boolean value "x" for -no-cache: strconv.ParseBool: parsing "x": invalid syntax`)
+	assert.Nil(t, fs.Lookup("no-cache"))
+
+	args := split("--no-cache=false -no-keep 1 -no-color x -no-cache")
+	require.NoError(t, fs.parse(args))
+	assert.True(t, v.Cache)
+	assert.False(t, *v.Color)
+	assert.Equal(t, 1, v.NoKeep)
+	assert.Equal(t, split("x -no-cache"), fs.Args())
+	assert.Equal(t, split("--no-cache=false -no-keep 1 -no-color x -no-cache"), args)
+
+	w := newWriter(nil)
+	w.flags(fs.flags)
+	assert.Equal(t, Dedent(`
+		  -[no-]cache
+		    	 (default true)
+		  -[no-]color
+		    	
+		  -keep
+		    	
+		  -no-keep int
+		    	
+	`)[1:], w.String())
+}
+
+func TestCount(t *testing.T) {
+	var v struct {
+		V   Count  `cli:"v,"`
+		Q   Count  `cli:"q,"`
+		VV  bool   `cli:"vv,"`
+		Out string `cli:"o,"`
+	}
+	fs := newFlagSet(&v)
+	require.NoError(t, fs.parse(split("-v -v -qqq --v -o -vvvv -vv x -vvv")))
+	assert.Equal(t, Count(3), v.V)
+	assert.Equal(t, Count(3), v.Q)
+	assert.True(t, v.VV)
+	assert.Equal(t, "-vvvv", v.Out)
+	assert.Equal(t, split("x -vvv"), fs.Args())
+
+	require.NoError(t, fs.parse(split("-v=5 -q=false -v")))
+	assert.Equal(t, Count(6), v.V)
+	assert.Equal(t, Count(0), v.Q)
+	assert.Equal(t, "6", fs.Lookup("v").Value.String())
+	assert.EqualError(t, fs.Set("v", "x"), `cli: invalid count "x"`)
+
+	args := split("-qq -v")
+	assert.Equal(t, split("-q -q -v"), fs.expandCounts(args))
+	assert.Equal(t, split("-qq -v"), args)
+	args = split("-v -- -vv")
+	assert.Equal(t, args, fs.expandCounts(args))
+	assert.Equal(t, split("-q -o -vv -ab"), fs.expandCounts(split("-q -o -vv -ab")))
+}
+
+func TestFlagAlias(t *testing.T) {
+	warn, restore := interceptWarn()
+	defer restore()
+
+	var v struct {
+		Out     string `cli:"output|o|~out,Output {file}"`
+		Verbose bool   `cli:"|v,Verbose output"`
+	}
+	fs := newFlagSet(&v)
+	require.Len(t, fs.flags, 2)
+	assert.Equal(t, []string{"o"}, fs.flags[0].Alias)
+	assert.Equal(t, []string{"out"}, fs.flags[0].old)
+	assert.Equal(t, "output", fs.lookup("o").Name)
+	assert.Equal(t, "output", fs.lookup("out").Name)
+	assert.Equal(t, "verbose", fs.lookup("no-verbose").Name)
+	assert.Nil(t, fs.lookup("no-v"))
+
+	require.NoError(t, fs.parse(split("-o=a -v")))
+	assert.Equal(t, "a", v.Out)
+	assert.True(t, v.Verbose)
+	assert.Empty(t, *warn)
+
+	require.NoError(t, fs.parse(split("-out=b -no-verbose")))
+	assert.Equal(t, "b", v.Out)
+	assert.False(t, v.Verbose)
+	assert.Equal(t, []string{`"-out" is deprecated, use "-output" instead`}, *warn)
+
+	w := newWriter(nil)
+	w.flags(fs.flags)
+	assert.Equal(t, Dedent(`
+		  -output, -o file
+		    	Output file
+		  -[no-]verbose, -v
+		    	Verbose output
+	`)[1:], w.String())
+
+	type Bad struct {
+		X bool `cli:"x|~,"`
+	}
+	assert.PanicsWithValue(t, "cli: missing flag alias: x", func() { NewFlagSet(new(Bad)) })
+}
@@ -2,9 +2,11 @@ package cli
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"io"
 	"runtime/debug"
+	"sort"
 	"strings"
 )
 
@@ -58,13 +60,23 @@ func (w *Writer) help() {
 	if w.cmds != nil {
 		w.Section("Commands")
 		w.commands()
+		if aliases := w.userAliases(); len(aliases) > 0 {
+			w.Section("Aliases")
+			w.aliasList(aliases)
+		}
 	} else {
 		noOpts := w.Len()
 		w.Section("Options")
-		fs := NewFlagSet(cmd)
-		fs.SetOutput(&w.Buffer)
 		ref := w.Len()
-		if fs.PrintDefaults(); ref == w.Len() {
+		fs := newFlagSet(cmd)
+		flags := fs.flags
+		if DebugFlag != "" && fs.Lookup(DebugFlag) == nil {
+			flags = append(flags, debugHelpFlag())
+			sort.SliceStable(flags, func(i, j int) bool {
+				return flags[i].Name < flags[j].Name
+			})
+		}
+		if w.flags(flags); ref == w.Len() {
 			w.Truncate(noOpts)
 		}
 	}
@@ -101,14 +113,19 @@ func (w *Writer) usage() {
 
 // commands writes a list of all commands with their summaries to w.
 func (w *Writer) commands() {
-	cmds, maxLen := w.Children(), 0
+	cmds, plugins, maxLen := w.Children(), w.Plugins(), 0
 	for _, c := range cmds {
-		if name := Name(c); maxLen < len(name) && !c.Hide {
+		if name := Name(c); maxLen < len(name) && !hidden(c) {
+			maxLen = len(name)
+		}
+	}
+	for _, name := range plugins {
+		if maxLen < len(name) {
 			maxLen = len(name)
 		}
 	}
 	for _, c := range cmds {
-		if !c.Hide {
+		if !hidden(c) {
 			if c.Summary == "" {
 				fmt.Fprintf(w, "  %s\n", Name(c))
 			} else {
@@ -116,20 +133,72 @@ func (w *Writer) commands() {
 			}
 		}
 	}
+	for _, name := range plugins {
+		fmt.Fprintf(w, "  %-*s  %s\n", maxLen, name, pluginMarker)
+	}
 }
 
-// done writes the buffer to out and calls Exit.
-func (w *Writer) done(out io.Writer, code int) {
-	defer Exit(2)
-	if p := recover(); p != nil {
-		w.WriteString("panic: ")
-		fmt.Fprintln(w, p)
+// typeNamer is implemented by flag.Value types that have a more descriptive
+// type name for help output than the generic "value".
+type typeNamer interface{ typeName() string }
+
+// flags writes the descriptions of all visible flags to w using the same
+// format as flag.PrintDefaults.
+func (w *Writer) flags(flags []*Flag) {
+	for _, f := range flags {
+		if f.Hidden || f.Deprecated != "" {
+			continue
+		}
+		line := w.Len()
+		if w.WriteString("  -"); f.Negatable {
+			w.WriteString("[no-]")
+		}
+		w.WriteString(f.Name)
+		for _, alias := range f.Alias {
+			w.WriteString(", -")
+			w.WriteString(alias)
+		}
+		name, usage := flag.UnquoteUsage(f.Flag)
+		if t, ok := f.Value.(typeNamer); ok && name == "value" {
+			name = t.typeName()
+		}
+		if name != "" {
+			w.WriteByte(' ')
+			w.WriteString(name)
+		}
+		if w.Len()-line <= 4 {
+			w.WriteByte('\t')
+		} else {
+			w.WriteString("\n    \t")
+		}
+		w.WriteString(strings.ReplaceAll(usage, "\n", "\n    \t"))
+		if f.def != "" {
+			fmt.Fprintf(w, " (default %s)", f.def)
+		}
 		w.WriteByte('\n')
-		w.Write(debug.Stack())
-		code = 2
 	}
+}
+
+// render calls fn to write to w and returns code. If fn panics, the panic
+// value and stack trace are written to w and the returned code is 2.
+func (w *Writer) render(fn func(), code int) (rc int) {
+	defer func() {
+		if p := recover(); p != nil {
+			w.WriteString("panic: ")
+			fmt.Fprintln(w, p)
+			w.WriteByte('\n')
+			w.Write(debug.Stack())
+			rc = 2
+		}
+	}()
+	fn()
+	return code
+}
+
+// flush writes the buffer to out and returns code.
+func (w *Writer) flush(out io.Writer, code int) int {
 	w.WriteTo(out)
-	Exit(code)
+	return code
 }
 
 // Dedent removes leading tab characters from each line in s. The first line is
@@ -161,6 +230,9 @@ func Dedent(s string) string {
 	}
 }
 
+// hidden returns true if c should be omitted from the command list.
+func hidden(c *Cfg) bool { return c.Hide || c.Deprecated != "" }
+
 // isHelp returns true if s represents a command-line request for help.
 func isHelp(s string) bool {
 	switch s {
@@ -10,6 +10,10 @@ import (
 
 type helpCmd struct {
 	Opt string `cli:"Option description"`
+	Def int    `cli:"Default value"`
+	Tag []int  `cli:",split,Tag {ids}"`
+	Old string `cli:",deprecated=-opt,Old option"`
+	Dbg bool   `cli:",hidden,Debug option"`
 }
 
 func (*helpCmd) Main(args []string) error { return nil }
@@ -30,11 +34,12 @@ func TestHelp(t *testing.T) {
 	})
 	c2 := g.Add(&Cfg{Name: "c2"})
 	c3 := c2.Add(&Cfg{
-		Name:    "c3|c",
+		Name:    "c3|c|~old",
 		Usage:   "usage",
 		Summary: "Command 3",
-		New:     func() Cmd { return &helpCmd{} },
+		New:     func() Cmd { return &helpCmd{Def: 1, Tag: []int{1, 2}} },
 	})
+	c2.Add(&Cfg{Name: "c4", Deprecated: "c3"})
 	Bin = "bin"
 	assert.Equal(t, Dedent(`
 		Usage: bin <command> [options] ...
@@ -73,8 +78,12 @@ func TestHelp(t *testing.T) {
 		Next paragraph.
 
 		Options:
+		  -def int
+		    	Default value (default 1)
 		  -opt string
 		    	Option description
+		  -tag ids
+		    	Tag ids (default 1,2)
 
 	`)[1:], c3.Help().String())
 }
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Verbosity adjusts the minimum level of messages written by loggers. Each
+// positive step enables messages that are one level more verbose (e.g. 1
+// enables LevelDebug), and each negative step suppresses one level, except for
+// LevelError messages, which are always written. It is set automatically for
+// commands that embed LogFlags.
+var Verbosity int
+
+// LogTime is the time layout used to prefix log messages with a timestamp. No
+// timestamps are written if it is empty.
+var LogTime string
+
+// LogOutput is the destination of log messages. If nil, the stderr writer of
+// Cfg.Execute (os.Stderr by default) is used.
+var LogOutput io.Writer
+
+// Log is the default logger.
+var Log Logger
+
+// logMu serializes writes to LogOutput.
+var logMu sync.Mutex
+
+// Level is the severity of a log message. Level values are the same as those of
+// log/slog levels.
+type Level int
+
+// Standard log levels. More verbose levels are lower than LevelDebug in steps
+// of 4 (e.g. LevelDebug-4 for trace messages).
+const (
+	LevelDebug Level = -4
+	LevelInfo  Level = 0
+	LevelWarn  Level = 4
+	LevelError Level = 8
+)
+
+// String returns the level name in the same format as log/slog.
+func (l Level) String() string {
+	name := func(base string, d Level) string {
+		if d == 0 {
+			return base
+		}
+		return fmt.Sprintf("%s%+d", base, d)
+	}
+	switch {
+	case l < LevelInfo:
+		return name("DEBUG", l-LevelDebug)
+	case l < LevelWarn:
+		return name("INFO", l-LevelInfo)
+	case l < LevelError:
+		return name("WARN", l-LevelWarn)
+	default:
+		return name("ERROR", l-LevelError)
+	}
+}
+
+// prefix returns the message prefix for level l.
+func (l Level) prefix() string {
+	switch {
+	case l < LevelInfo:
+		return "Debug: "
+	case l < LevelWarn:
+		return ""
+	case l < LevelError:
+		return "Warning: "
+	default:
+		return "Error: "
+	}
+}
+
+// LogFlags defines verbosity flags. Commands that embed it have Verbosity set
+// to the number of -v flags minus the number of -q flags before Main is
+// called.
+type LogFlags struct {
+	Verbose Count `cli:"v|verbose,Increase output verbosity"`
+	Quiet   Count `cli:"q|quiet,Decrease output verbosity"`
+}
+
+// verbosity returns the Verbosity value specified by f.
+func (f *LogFlags) verbosity() int { return int(f.Verbose) - int(f.Quiet) }
+
+// verbosityCmd is implemented by commands that embed LogFlags.
+type verbosityCmd interface{ verbosity() int }
+
+// Logger writes leveled log messages. The minimum level of messages that are
+// written is determined by Verbosity and by the DebugLevel of the logger name,
+// whichever is more verbose. For example, a logger named "net" writes
+// LevelDebug-4 messages if DebugSpec contains "net=2". The zero value is a
+// logger without a name, which writes debug messages when Debug is set and
+// DebugSpec is empty.
+type Logger struct {
+	Name string // Subsystem name, which is also added to each message
+}
+
+// Level returns the minimum level of messages written by l.
+func (l Logger) Level() Level {
+	v := Verbosity
+	if d := DebugLevel(l.Name); d > 0 && d > v {
+		v = d
+	}
+	if lvl := LevelInfo - Level(4*v); lvl < LevelError {
+		return lvl
+	}
+	return LevelError
+}
+
+// Enabled returns whether l writes messages at the specified level.
+func (l Logger) Enabled(level Level) bool { return level >= l.Level() }
+
+// Logf writes a message at the specified level if it is enabled.
+func (l Logger) Logf(level Level, format string, v ...interface{}) {
+	if l.Enabled(level) {
+		l.output(time.Now(), level, fmt.Sprintf(format, v...))
+	}
+}
+
+// Debugf writes a LevelDebug message.
+func (l Logger) Debugf(format string, v ...interface{}) { l.Logf(LevelDebug, format, v...) }
+
+// Infof writes a LevelInfo message.
+func (l Logger) Infof(format string, v ...interface{}) { l.Logf(LevelInfo, format, v...) }
+
+// Warnf writes a LevelWarn message.
+func (l Logger) Warnf(format string, v ...interface{}) { l.Logf(LevelWarn, format, v...) }
+
+// Errorf writes a LevelError message.
+func (l Logger) Errorf(format string, v ...interface{}) { l.Logf(LevelError, format, v...) }
+
+// output writes a message to LogOutput without checking the level.
+func (l Logger) output(t time.Time, level Level, msg string) {
+	var b strings.Builder
+	if LogTime != "" {
+		if t.IsZero() {
+			t = time.Now()
+		}
+		b.WriteString(t.Format(LogTime))
+		b.WriteByte(' ')
+	}
+	b.WriteString(level.prefix())
+	if l.Name != "" {
+		b.WriteString(l.Name)
+		b.WriteString(": ")
+	}
+	b.WriteString(msg)
+	if !strings.HasSuffix(msg, "\n") {
+		b.WriteByte('\n')
+	}
+	logMu.Lock()
+	defer logMu.Unlock()
+	w := LogOutput
+	if w == nil {
+		w = errWriter()
+	}
+	io.WriteString(w, b.String())
+}
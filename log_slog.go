@@ -0,0 +1,90 @@
+//go:build go1.21
+// +build go1.21
+
+package cli
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// Slog returns a slog.Logger that writes messages via l.
+func (l Logger) Slog() *slog.Logger { return slog.New(l.Handler()) }
+
+// Handler returns a slog.Handler that writes records via l. Record attributes
+// are appended to the message as space-separated key=value pairs.
+func (l Logger) Handler() slog.Handler { return &slogHandler{l: l} }
+
+// slogHandler implements slog.Handler for Logger.
+type slogHandler struct {
+	l     Logger
+	attrs string // Formatted attributes from WithAttrs
+	group string // Key prefix from WithGroup
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.l.Enabled(Level(level))
+}
+
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(r.Message)
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(&b, h.group, a)
+		return true
+	})
+	h.l.output(r.Time, Level(r.Level), b.String())
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	var b strings.Builder
+	b.WriteString(h.attrs)
+	for _, a := range attrs {
+		appendAttr(&b, h.group, a)
+	}
+	c := *h
+	c.attrs = b.String()
+	return &c
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.group += name + "."
+	return &c
+}
+
+// appendAttr writes attribute a to b as " key=value", prefixing the key with
+// group. Group attributes are flattened into dot-separated keys.
+func appendAttr(b *strings.Builder, group string, a slog.Attr) {
+	if a.Value = a.Value.Resolve(); a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			group += a.Key + "."
+		}
+		for _, g := range a.Value.Group() {
+			appendAttr(b, group, g)
+		}
+		return
+	}
+	v := a.Value.String()
+	if v == "" || strings.ContainsAny(v, " \t\n\"=") {
+		v = strconv.Quote(v)
+	}
+	b.WriteByte(' ')
+	b.WriteString(group)
+	b.WriteString(a.Key)
+	b.WriteByte('=')
+	b.WriteString(v)
+}
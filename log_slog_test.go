@@ -0,0 +1,37 @@
+//go:build go1.21
+// +build go1.21
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlog(t *testing.T) {
+	defer saveLog()()
+	Debug, DebugSpec, Verbosity, LogTime = false, "", 0, ""
+	var b bytes.Buffer
+	LogOutput = &b
+
+	log := Logger{"db"}.Slog()
+	log.Debug("hidden")
+	log.Info("query", "sql", "select 1", "rows", 2)
+	log.With("id", 7).WithGroup("g").Warn("slow", slog.Group("t", "ms", 50), "empty", "")
+	log.Error("failed", slog.Group("", "a", "b"))
+	assert.Equal(t, `db: query sql="select 1" rows=2
+Warning: db: slow id=7 g.t.ms=50 g.empty=""
+Error: db: failed a=b
+`, b.String())
+
+	b.Reset()
+	Debug, DebugSpec = true, "db"
+	log.Debug("shown")
+	assert.True(t, log.Enabled(context.Background(), slog.LevelDebug))
+	assert.False(t, log.Enabled(context.Background(), slog.LevelDebug-4))
+	assert.Equal(t, "Debug: db: shown\n", b.String())
+}
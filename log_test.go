@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func saveLog() func() {
+	d, s, v, t, o := Debug, DebugSpec, Verbosity, LogTime, LogOutput
+	return func() { Debug, DebugSpec, Verbosity, LogTime, LogOutput = d, s, v, t, o }
+}
+
+func TestLevel(t *testing.T) {
+	tests := []*struct {
+		l    Level
+		want string
+	}{
+		{LevelDebug - 4, "DEBUG-4"},
+		{LevelDebug, "DEBUG"},
+		{LevelInfo, "INFO"},
+		{LevelInfo + 2, "INFO+2"},
+		{LevelWarn, "WARN"},
+		{LevelError, "ERROR"},
+		{LevelError + 1, "ERROR+1"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, tc.l.String())
+	}
+}
+
+func TestLogger(t *testing.T) {
+	defer saveLog()()
+	Debug, DebugSpec, Verbosity, LogTime = false, "", 0, ""
+	var b bytes.Buffer
+	LogOutput = &b
+	net := Logger{"net"}
+
+	log := func() {
+		Log.Debugf("d%d", 1)
+		Log.Infof("i%d", 1)
+		Log.Warnf("w%d", 1)
+		Log.Errorf("e%d", 1)
+		net.Logf(LevelDebug-4, "trace\n")
+		net.Debugf("d%d", 2)
+		net.Infof("i%d", 2)
+	}
+	tests := []*struct {
+		debug     bool
+		spec      string
+		verbosity int
+		want      string
+	}{{
+		want: "i1\nWarning: w1\nError: e1\nnet: i2\n",
+	}, {
+		verbosity: -1,
+		want:      "Warning: w1\nError: e1\n",
+	}, {
+		verbosity: -5,
+		want:      "Error: e1\n",
+	}, {
+		verbosity: 1,
+		want:      "Debug: d1\ni1\nWarning: w1\nError: e1\nDebug: net: d2\nnet: i2\n",
+	}, {
+		debug:     true,
+		verbosity: -1,
+		want:      "Debug: d1\ni1\nWarning: w1\nError: e1\nDebug: net: d2\nnet: i2\n",
+	}, {
+		debug:     true,
+		spec:      "net=2",
+		verbosity: -1,
+		want:      "Warning: w1\nError: e1\nDebug: net: trace\nDebug: net: d2\nnet: i2\n",
+	}}
+	for _, tc := range tests {
+		Debug, DebugSpec, Verbosity = tc.debug, tc.spec, tc.verbosity
+		b.Reset()
+		log()
+		assert.Equal(t, tc.want, b.String(), "%+v", tc)
+	}
+
+	Debug, DebugSpec, Verbosity = false, "", 0
+	b.Reset()
+	LogTime = "2006"
+	net.output(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), LevelWarn, "msg")
+	assert.Equal(t, "2020 Warning: net: msg\n", b.String())
+
+	LogTime, LogOutput = "", nil
+	out := interceptWrite(&os.Stderr)
+	Log.Infof("stderr")
+	assert.Equal(t, "stderr\n", out())
+}
+
+func TestLogFlags(t *testing.T) {
+	defer saveLog()()
+	type logCmd struct {
+		LogFlags
+		testCmd
+	}
+	var main Cfg
+	var v int
+	main.Add(&Cfg{Name: "log", New: func() Cmd {
+		return &logCmd{testCmd: testCmd{func([]string) error {
+			v = Verbosity
+			return nil
+		}}}
+	}})
+	var b bytes.Buffer
+	assert.Equal(t, 0, main.Execute(nil, &b, split("log -vvv -q")))
+	assert.Equal(t, 2, v)
+	assert.Equal(t, 0, main.Execute(nil, &b, split("log --quiet")))
+	assert.Equal(t, -1, v)
+}
@@ -14,13 +14,23 @@ var Bin = filepath.Base(os.Args[0])
 // Debug determines whether to print debugging information.
 var Debug bool
 
-// DebugSpec is set via DebugFromEnv to the contents of the specified
-// environment variable. It is not used by the cli package itself.
+// DebugSpec is set via DebugFromEnv or DebugFlag to a GODEBUG-style spec that
+// enables debugging of specific subsystems. See DebugLevel.
 var DebugSpec string
 
 // Exit is called by Cfg.Run() to terminate the process.
 var Exit = os.Exit
 
+// ExitPanic is a panic value for replacements of Exit that need to unwind the
+// stack of the running command, such as in tests. Cfg.Execute does not recover
+// panics with this value.
+type ExitPanic int
+
+// Warn is called to report non-fatal problems, such as the use of deprecated
+// commands or flags. The default implementation writes to the stderr writer of
+// Cfg.Execute.
+var Warn = func(msg string) { fmt.Fprintf(errWriter(), "Warning: %s\n", msg) }
+
 // Main is the common root of all commands in a CLI program. It is normally
 // called as follows:
 //
@@ -62,6 +72,11 @@ func Errorf(format string, v ...interface{}) UsageError {
 	return UsageError(fmt.Sprintf(format, v...))
 }
 
+// ExitCoder is implemented by errors that set a specific exit code. Cfg.Run
+// recognizes it anywhere in the error chain (see errors.As), prints the error
+// message, and exits with the returned code.
+type ExitCoder interface{ ExitCode() int }
+
 // ExitCode is an error that sets the exit code without printing any message.
 type ExitCode int
 
@@ -70,6 +85,9 @@ func (e ExitCode) Error() string {
 	return fmt.Sprintf("exit code %d", int(e))
 }
 
+// ExitCode implements ExitCoder.
+func (e ExitCode) ExitCode() int { return int(e) }
+
 // Sum returns the number of arguments that are true. This can be used to test
 // for mutually exclusive flags.
 func Sum(v ...bool) int {
@@ -7,6 +7,12 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+func TestMain(m *testing.M) {
+	// Do not read the alias file of the current user
+	AliasFile = func() string { return "" }
+	os.Exit(m.Run())
+}
+
 func TestDebugFromEnv(t *testing.T) {
 	tests := []*struct {
 		unset bool
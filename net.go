@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	ipNetType = reflect.TypeOf(net.IPNet{})
+	macType   = reflect.TypeOf(net.HardwareAddr(nil))
+	urlType   = reflect.TypeOf(url.URL{})
+)
+
+// HostPort is a network address in "host:port" format. The host may be empty
+// or a host name, IPv4, or IPv6 address. The port must be a non-zero number.
+type HostPort struct {
+	Host string
+	Port uint16
+}
+
+// String implements flag.Value.
+func (hp HostPort) String() string {
+	if hp == (HostPort{}) {
+		return ""
+	}
+	return net.JoinHostPort(hp.Host, strconv.Itoa(int(hp.Port)))
+}
+
+// Set implements flag.Value.
+func (hp *HostPort) Set(s string) error {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		return err
+	}
+	if strings.ContainsAny(host, " \t/") {
+		return fmt.Errorf("cli: invalid host in %q", s)
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || p == 0 {
+		return fmt.Errorf("cli: invalid port in %q", s)
+	}
+	hp.Host, hp.Port = host, uint16(p)
+	return nil
+}
+
+// Get implements flag.Getter.
+func (hp HostPort) Get() interface{} { return hp }
+
+// parseIPNet parses s as a CIDR address. Unlike net.ParseCIDR, the returned IP
+// is the one in s rather than the network address (e.g. "10.1.2.3/8" is kept as
+// is instead of becoming "10.0.0.0/8").
+func parseIPNet(s string) (reflect.Value, error) {
+	ip, n, err := net.ParseCIDR(s)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if len(n.Mask) == net.IPv4len {
+		ip = ip.To4()
+	}
+	return reflect.ValueOf(net.IPNet{IP: ip, Mask: n.Mask}), nil
+}
+
+// parseMAC parses s as a hardware address.
+func parseMAC(s string) (reflect.Value, error) {
+	mac, err := net.ParseMAC(s)
+	return reflect.ValueOf(mac), err
+}
+
+// parseURL returns a parseFunc for URLs. If schemes is not empty, it specifies
+// '|'-separated list of allowed URL schemes.
+func parseURL(schemes string) parseFunc {
+	return func(s string) (reflect.Value, error) {
+		u, err := url.Parse(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if schemes != "" {
+			ok := false
+			for _, scheme := range strings.Split(schemes, "|") {
+				if strings.EqualFold(u.Scheme, scheme) {
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				return reflect.Value{}, fmt.Errorf(
+					"cli: unsupported URL scheme in %q (want %s)", s, schemes)
+			}
+		}
+		return reflect.ValueOf(u).Elem(), nil
+	}
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetFlags(t *testing.T) {
+	var v struct {
+		IP     net.IP           `cli:""`
+		Net    net.IPNet        `cli:""`
+		NetPtr *net.IPNet       `cli:""`
+		Nets   []net.IPNet      `cli:",split,"`
+		MAC    net.HardwareAddr `cli:""`
+		Addr   HostPort         `cli:""`
+		Addrs  []HostPort       `cli:""`
+		URL    url.URL          `cli:""`
+		Web    *url.URL         `cli:",scheme=http|https,"`
+	}
+	v.Addr = HostPort{"localhost", 80}
+	fs := newFlagSet(&v)
+	assert.Equal(t, "localhost:80", fs.Lookup("addr").DefValue)
+	assert.Equal(t, "", fs.Lookup("web").DefValue)
+	require.NoError(t, fs.parse([]string{
+		"-ip=::1",
+		"-net=10.1.2.3/8",
+		"-net-ptr=192.168.0.0/16",
+		"-nets=10.0.0.0/8,fd00::/8",
+		"-mac=00:00:5e:00:53:01",
+		"-addr=[::1]:8080",
+		"-addrs=:1", "-addrs=a:2",
+		"-url=file:///tmp",
+		"-web=HTTPS://example.com/path",
+	}))
+
+	cidr := func(s string) net.IPNet {
+		_, n, err := net.ParseCIDR(s)
+		require.NoError(t, err)
+		return *n
+	}
+	n := cidr("192.168.0.0/16")
+	assert.Equal(t, net.ParseIP("::1"), v.IP)
+	assert.Equal(t, net.IPNet{IP: net.IPv4(10, 1, 2, 3).To4(), Mask: net.CIDRMask(8, 32)}, v.Net)
+	assert.Equal(t, &n, v.NetPtr)
+	assert.Equal(t, []net.IPNet{cidr("10.0.0.0/8"), cidr("fd00::/8")}, v.Nets)
+	assert.Equal(t, net.HardwareAddr{0, 0, 0x5e, 0, 0x53, 1}, v.MAC)
+	assert.Equal(t, HostPort{"::1", 8080}, v.Addr)
+	assert.Equal(t, []HostPort{{"", 1}, {"a", 2}}, v.Addrs)
+	assert.Equal(t, "file:///tmp", v.URL.String())
+	assert.Equal(t, "example.com", v.Web.Host)
+
+	assert.Equal(t, "10.1.2.3/8", fs.Lookup("net").Value.String())
+	assert.Equal(t, "10.0.0.0/8,fd00::/8", fs.Lookup("nets").Value.String())
+	assert.Equal(t, "00:00:5e:00:53:01", fs.Lookup("mac").Value.String())
+	assert.Equal(t, "[::1]:8080", fs.Lookup("addr").Value.String())
+	assert.Equal(t, "https://example.com/path", fs.Lookup("web").Value.String())
+
+	assert.Error(t, fs.Set("net", "10.0.0.0"))
+	assert.Error(t, fs.Set("mac", "x"))
+	assert.Error(t, fs.Set("addr", "a"))
+	assert.EqualError(t, fs.Set("addr", "a:x"), `cli: invalid port in "a:x"`)
+	assert.EqualError(t, fs.Set("addr", "a:65536"), `cli: invalid port in "a:65536"`)
+	assert.EqualError(t, fs.Set("addr", "a:0"), `cli: invalid port in "a:0"`)
+	assert.EqualError(t, fs.Set("addr", "a b:1"), `cli: invalid host in "a b:1"`)
+	assert.EqualError(t, fs.Set("web", "ftp://x"),
+		`cli: unsupported URL scheme in "ftp://x" (want http|https)`)
+	assert.Error(t, fs.Set("url", "%"))
+}
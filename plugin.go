@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// EnablePlugins enables external plugin commands. When Cfg.Parse encounters an
+// unknown command, it looks for an executable named "<Bin>-<command>" on PATH,
+// or "<Bin>-<parent>-<command>" for sub-commands, and runs it with the
+// remaining arguments. Plugins cannot shadow commands registered via Cfg.Add.
+// Plugins are disabled by default.
+var EnablePlugins bool
+
+// pluginMarker is shown in place of the summary for plugin commands in help
+// output.
+const pluginMarker = "(plugin)"
+
+// Plugins returns the names of all plugin commands of c found on PATH, sorted
+// by name.
+func (c *Cfg) Plugins() []string {
+	if !EnablePlugins || c.cmds == nil {
+		return nil
+	}
+	prefix := pluginPrefix(c)
+	seen := make(map[string]bool)
+	var names []string
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			dir = "."
+		}
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, fi := range files {
+			name := fi.Name()
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if !strings.HasPrefix(name, prefix) || !isExecutable(fi) {
+				continue
+			}
+			name = name[len(prefix):]
+			if !isPluginName(name) || seen[name] || c.cmds[name] != nil {
+				continue
+			}
+			if i := strings.IndexByte(name, '-'); i > 0 {
+				if sub := c.cmds[name[:i]]; sub != nil && sub.cmds != nil {
+					continue // Plugin of a sub-command
+				}
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// plugin returns the config and command for plugin name of c, or nil if the
+// plugin does not exist.
+func (c *Cfg) plugin(name string) (*Cfg, Cmd) {
+	if !EnablePlugins || !isPluginName(name) {
+		return nil, nil
+	}
+	path, err := exec.LookPath(pluginPrefix(c) + name)
+	if err != nil {
+		return nil, nil
+	}
+	cfg := &Cfg{Name: name, MaxArgs: -1, parent: c}
+	return cfg, &pluginCmd{path: path}
+}
+
+// pluginPrefix returns the executable name prefix for plugins of c.
+func pluginPrefix(c *Cfg) string {
+	if c.parent != nil {
+		return pluginPrefix(c.parent) + Name(c) + "-"
+	}
+	bin := Bin
+	if runtime.GOOS == "windows" {
+		bin = strings.TrimSuffix(bin, filepath.Ext(bin))
+	}
+	return bin + "-"
+}
+
+// isPluginName returns true if name is a valid plugin command name.
+func isPluginName(name string) bool {
+	return name != "" && name[0] != '-' && name[0] != '.' &&
+		!strings.ContainsAny(name, `/\`+string(filepath.ListSeparator))
+}
+
+// isExecutable returns true if fi describes an executable file. On Windows,
+// this is determined by the file extension, as in exec.LookPath.
+func isExecutable(fi os.FileInfo) bool {
+	if !fi.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return hasExecExt(fi.Name(), os.Getenv("PATHEXT"))
+	}
+	return fi.Mode()&0o111 != 0
+}
+
+// hasExecExt returns true if the extension of name is one of the executable
+// file extensions in pathExt, which has the format of the PATHEXT environment
+// variable. The default extensions of exec.LookPath are used if pathExt is
+// empty.
+func hasExecExt(name, pathExt string) bool {
+	if pathExt == "" {
+		pathExt = ".com;.exe;.bat;.cmd"
+	}
+	ext := filepath.Ext(name)
+	for _, e := range strings.Split(pathExt, ";") {
+		if e == "" {
+			continue
+		} else if e[0] != '.' {
+			e = "." + e
+		}
+		if strings.EqualFold(ext, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginCmd runs an external plugin command.
+type pluginCmd struct{ path string }
+
+func (cmd *pluginCmd) Main(args []string) error {
+	p := exec.Command(cmd.path, args...)
+	p.Stdin, p.Stdout, p.Stderr = os.Stdin, outWriter(), errWriter()
+	err := p.Run()
+	if e, ok := err.(*exec.ExitError); ok && e.ExitCode() > 0 {
+		return ExitCode(e.ExitCode())
+	}
+	return err
+}
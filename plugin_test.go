@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	dir, err := ioutil.TempDir("", t.Name())
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	require.NoError(t, os.Setenv("PATH", dir))
+	defer func(bin string) { Bin = bin }(Bin)
+	Bin = "bin"
+	defer func(v bool) { EnablePlugins = v }(EnablePlugins)
+	EnablePlugins = true
+
+	script := "#!/bin/sh\necho \"$0\" \"$@\"\nexit 3\n"
+	for _, name := range []string{"bin-p1", "bin-c1", "bin-grp-p2", "bin-grp-x-y", "bin-x-y", "other-p3"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0o755))
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "bin-noexec"), nil, 0o644))
+
+	var root Cfg
+	c1 := root.Add(&Cfg{Name: "c1", Summary: "Command 1", New: newTestCmd(nil)})
+	grp := root.Add(&Cfg{Name: "grp"})
+	grp.Add(&Cfg{Name: "c2", New: newTestCmd(nil)})
+	assert.Equal(t, []string{"p1", "x-y"}, root.Plugins())
+	assert.Equal(t, []string{"p2", "x-y"}, grp.Plugins())
+	assert.Nil(t, c1.Plugins())
+
+	// Registered commands take precedence
+	cfg, _, _, err := root.Parse(split("c1"))
+	require.NoError(t, err)
+	assert.Equal(t, c1, cfg)
+
+	cfg, cmd, args, err := root.Parse(split("grp p2 -x help"))
+	require.NoError(t, err)
+	assert.Equal(t, "bin grp p2", cfg.fullName(Bin))
+	assert.Equal(t, split("-x help"), args)
+
+	out := interceptWrite(&os.Stdout)
+	err = cmd.Main(args)
+	assert.Equal(t, filepath.Join(dir, "bin-grp-p2")+" -x help\n", out())
+	assert.Equal(t, ExitCode(3), err)
+
+	_, _, args, err = root.Parse(split("help p1 x"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--help"}, args)
+
+	for _, v := range []string{"noexec", "p3", "../bin-p1", "-p1"} {
+		_, _, _, err = root.Parse([]string{v})
+		assert.Equal(t, Errorf("unknown command %q", v), err)
+	}
+
+	assert.Equal(t, Dedent(`
+		Usage: bin grp <command> [options] ...
+		       bin grp <command> help
+		       bin grp help [command]
+
+		Commands:
+		  c2
+		  p2   (plugin)
+		  x-y  (plugin)
+
+	`)[1:], grp.Help().String())
+
+	// Plugin output is written to the Execute writers
+	script = "#!/bin/sh\necho out\necho err >&2\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "bin-err"), []byte(script), 0o755))
+	var o, e bytes.Buffer
+	assert.Equal(t, 0, root.Execute(&o, &e, split("err")))
+	assert.Equal(t, "out\n", o.String())
+	assert.Equal(t, "err\n", e.String())
+
+	EnablePlugins = false
+	assert.Nil(t, root.Plugins())
+	_, _, _, err = root.Parse(split("p1"))
+	assert.Equal(t, UsageError(`unknown command "p1"`), err)
+}
+
+func TestHasExecExt(t *testing.T) {
+	tests := []*struct {
+		name, pathExt string
+		want          bool
+	}{
+		{"bin-p.exe", "", true},
+		{"bin-p.CMD", "", true},
+		{"bin-notes.txt", "", false},
+		{"bin-p", "", false},
+		{"bin-p.ps1", ".COM;.EXE;.PS1", true},
+		{"bin-p.exe", ".COM;.PS1", false},
+		{"bin-p.py", "py;;", true},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, hasExecExt(tc.name, tc.pathExt), "%+v", tc)
+	}
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ReportError is called by Cfg.Execute to report errors returned by commands,
+// other than UsageError, ErrHelp, and ExitCode. The default implementation
+// writes the error message, followed by its cause chain and any hints. If Debug
+// is set, the detailed "%+v" error format is also written if it differs from
+// the message, which includes stack traces for errors that record them.
+var ReportError = func(w io.Writer, err error) {
+	msgs := causes(err)
+	fmt.Fprintf(w, "Error: %s\n", msgs[0])
+	for _, msg := range msgs[1:] {
+		fmt.Fprintf(w, "  caused by: %s\n", msg)
+	}
+	for _, hint := range Hints(err) {
+		fmt.Fprintf(w, "Hint: %s\n", hint)
+	}
+	if Debug {
+		if s := fmt.Sprintf("%+v", err); s != err.Error() {
+			fmt.Fprintf(w, "\n%s\n", strings.TrimRight(s, "\n"))
+		}
+	}
+}
+
+// Hinter is implemented by errors that provide a user-facing hint about how to
+// resolve the problem.
+type Hinter interface{ Hint() string }
+
+// WithHint returns an error that wraps err and implements Hinter. It returns
+// nil if err is nil.
+func WithHint(err error, hint string) error {
+	if err == nil {
+		return nil
+	}
+	return &hintError{err, hint}
+}
+
+// Hints returns all hints in the error chain of err, outermost first.
+func Hints(err error) []string {
+	var hints []string
+	for ; err != nil; err = errors.Unwrap(err) {
+		if h, ok := err.(Hinter); ok && h.Hint() != "" {
+			hints = append(hints, h.Hint())
+		}
+	}
+	return hints
+}
+
+// hintError attaches a hint to an error.
+type hintError struct {
+	err  error
+	hint string
+}
+
+func (e *hintError) Error() string { return e.err.Error() }
+func (e *hintError) Unwrap() error { return e.err }
+func (e *hintError) Hint() string  { return e.hint }
+
+// causes returns the messages of all errors in the chain of err. Each message
+// has the message of the next error removed from its end if it ends with
+// ": <message>", as is the case for errors created by fmt.Errorf with %w.
+// Messages that become empty are omitted, except the first one.
+func causes(err error) []string {
+	var msgs []string
+	for msg := err.Error(); err != nil; {
+		next, nextMsg := errors.Unwrap(err), ""
+		if next != nil {
+			nextMsg = next.Error()
+			if msg != nextMsg {
+				msg = strings.TrimSuffix(msg, ": "+nextMsg)
+			} else {
+				msg = ""
+			}
+		}
+		if msg != "" || len(msgs) == 0 && next == nil {
+			msgs = append(msgs, msg)
+		}
+		err, msg = next, nextMsg
+	}
+	return msgs
+}
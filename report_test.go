@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type detailErr struct{}
+
+func (detailErr) Error() string { return "detail" }
+
+func (e detailErr) Format(s fmt.State, verb rune) {
+	if s.Flag('+') {
+		fmt.Fprint(s, "detail\nstack trace\n")
+	} else {
+		fmt.Fprint(s, e.Error())
+	}
+}
+
+func TestCauses(t *testing.T) {
+	base := errors.New("base")
+	tests := []*struct {
+		err  error
+		want []string
+	}{
+		{base, []string{"base"}},
+		{errors.New(""), []string{""}},
+		{fmt.Errorf("a: %w", base), []string{"a", "base"}},
+		{fmt.Errorf("a %w", base), []string{"a base", "base"}},
+		{fmt.Errorf("%w", base), []string{"base"}},
+		{fmt.Errorf("a: %w", fmt.Errorf("b: %w", base)), []string{"a", "b", "base"}},
+		{WithHint(fmt.Errorf("a: %w", WithHint(base, "h2")), "h1"), []string{"a", "base"}},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, causes(tc.err), "%v", tc.err)
+	}
+}
+
+func TestHints(t *testing.T) {
+	assert.Nil(t, WithHint(nil, "hint"))
+	base := errors.New("base")
+	err := WithHint(fmt.Errorf("a: %w", WithHint(base, "h2")), "h1")
+	assert.Equal(t, "a: base", err.Error())
+	assert.True(t, errors.Is(err, base))
+	assert.Equal(t, []string{"h1", "h2"}, Hints(err))
+	assert.Nil(t, Hints(base))
+	assert.Nil(t, Hints(WithHint(base, "")))
+
+	var ec ExitCoder
+	assert.True(t, errors.As(WithHint(ExitCode(3), "h"), &ec))
+}
+
+func TestReportError(t *testing.T) {
+	defer func(v bool) { Debug = v }(Debug)
+	Debug = false
+	var b bytes.Buffer
+	err := WithHint(fmt.Errorf("login failed: %w", errors.New("token expired")), "run `bin login` first")
+	ReportError(&b, err)
+	assert.Equal(t, Dedent(`
+		Error: login failed
+		  caused by: token expired
+		Hint: run ` + "`bin login`" + ` first
+	`)[1:], b.String())
+
+	b.Reset()
+	ReportError(&b, fmt.Errorf("a: %w", detailErr{}))
+	assert.Equal(t, "Error: a\n  caused by: detail\n", b.String())
+
+	Debug = true
+	b.Reset()
+	ReportError(&b, errors.New("plain"))
+	assert.Equal(t, "Error: plain\n", b.String())
+
+	b.Reset()
+	ReportError(&b, detailErr{})
+	assert.Equal(t, "Error: detail\n\ndetail\nstack trace\n", b.String())
+}
@@ -0,0 +1,52 @@
+package cli_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mxk/go-cli"
+	"github.com/mxk/go-cli/clitest"
+	"github.com/stretchr/testify/assert"
+)
+
+type errCmd struct{ err error }
+
+func (cmd *errCmd) Main([]string) error { return cmd.err }
+
+func TestExit(t *testing.T) {
+	defer clitest.SaveGlobals()()
+	var cmd errCmd
+	cfg := cli.Cfg{New: func() cli.Cmd { return &cmd }}
+	cli.Bin = "bin"
+
+	cmd.err = cli.ErrHelp
+	r := clitest.Run(&cfg)
+	assert.Equal(t, &clitest.Result{Code: 0, Stderr: "Usage: bin\n       bin help\n\n"}, r)
+
+	cmd.err = cli.UsageError("usage error")
+	r = clitest.Run(&cfg)
+	assert.Equal(t, &clitest.Result{Code: 2, Stderr: "Error: usage error\nUsage: bin\n       bin help\n"}, r)
+
+	cmd.err = cli.ExitCode(42)
+	r = clitest.Run(&cfg)
+	assert.Equal(t, &clitest.Result{Code: 42}, r)
+
+	cmd.err = errors.New("fail")
+	r = clitest.Run(&cfg)
+	assert.Equal(t, &clitest.Result{Code: 1, Stderr: "Error: fail\n"}, r)
+}
+
+func TestNilCmd(t *testing.T) {
+	var main cli.Cfg
+	r := clitest.Run(&main)
+	assert.Equal(t, &clitest.Result{Code: 2, Stderr: "Command not implemented\n"}, r)
+
+	main.Add(&cli.Cfg{Name: "c1"})
+	main.Add(&cli.Cfg{Name: "c2", Summary: "Command 2"})
+	r = clitest.Run(&main)
+	assert.Equal(t, &clitest.Result{Code: 2, Stderr: cli.Dedent(`
+		Specify command:
+		  c1
+		  c2  Command 2
+	`)[1:]}, r)
+}
@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Shell is an interactive session that executes commands from a Cfg tree. Each
+// input line is split into arguments using shell-like quoting rules and
+// dispatched via Cfg.Parse and Cmd.Main. Errors, including calls to Exit, end
+// the current command, but not the session. Lines with unterminated quotes or
+// a trailing backslash are continued on the next line. In addition to the
+// commands of Cfg, the shell supports the following built-in commands:
+//
+//	exit [code]  End the session
+//	history      List previous command lines
+//	!!           Repeat the last command line
+//	!<n>         Repeat command line n
+type Shell struct {
+	Cfg     *Cfg      // Root command
+	Prompt  string    // Input prompt
+	In      io.Reader // Command line source (default os.Stdin)
+	Out     io.Writer // Prompt, help, and error output (default Execute stderr)
+	History []string  // Previous command lines
+}
+
+// ShellCmd returns a new "shell" command config, which starts an interactive
+// Shell for the root of the command tree that it is added to:
+//
+//	cli.Main.Add(cli.ShellCmd())
+func ShellCmd() *Cfg {
+	c := &Cfg{Name: "shell", Summary: "Start interactive shell"}
+	c.New = func() Cmd { return &shellCmd{c} }
+	return c
+}
+
+// shellCmd implements the command returned by ShellCmd.
+type shellCmd struct{ cfg *Cfg }
+
+func (cmd *shellCmd) Main([]string) error {
+	root := cmd.cfg
+	for root.parent != nil {
+		root = root.parent
+	}
+	sh := Shell{Cfg: root, Prompt: Bin + "> "}
+	return sh.Run()
+}
+
+// Run executes commands until the exit command or the end of input. It returns
+// ExitCode if exit is called with a non-zero code.
+func (sh *Shell) Run() error {
+	in, out := sh.In, sh.Out
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = errWriter()
+	}
+	r, cont := bufio.NewReader(in), ""
+	if sh.Prompt != "" {
+		cont = "> "
+	}
+	for {
+		line, err := readLine(r, out, sh.Prompt, cont)
+		if line = strings.TrimSpace(line); line != "" {
+			if code, exit := sh.exec(out, line); exit {
+				if code != 0 {
+					return ExitCode(code)
+				}
+				return nil
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				if sh.Prompt != "" {
+					fmt.Fprintln(out)
+				}
+				err = nil
+			}
+			return err
+		}
+	}
+}
+
+// readLine reads one command line from r, which may span multiple lines of
+// input if quotes or escapes are not terminated. The prompt is written to out
+// before the first line and cont before each continuation line.
+func readLine(r *bufio.Reader, out io.Writer, prompt, cont string) (string, error) {
+	var line string
+	for {
+		if prompt != "" {
+			io.WriteString(out, prompt)
+		}
+		s, err := r.ReadString('\n')
+		if line += s; err != nil {
+			return line, err
+		}
+		_, err = splitArgs(strings.TrimRight(line, "\r\n"))
+		if err != errQuote && err != errEscape {
+			return line, nil
+		}
+		prompt = cont
+	}
+}
+
+// exec executes one command line and returns its exit code. It returns true
+// for exit if the session should end.
+func (sh *Shell) exec(out io.Writer, line string) (code int, exit bool) {
+	if line[0] == '!' {
+		i := len(sh.History)
+		if line != "!!" {
+			n, err := strconv.Atoi(line[1:])
+			if i = n; err != nil || n < 1 {
+				i = 0
+			}
+		}
+		if i < 1 || len(sh.History) < i {
+			fmt.Fprintf(out, "Error: %s: event not found\n", line)
+			return 1, false
+		}
+		line = sh.History[i-1]
+		fmt.Fprintln(out, line)
+	}
+	if n := len(sh.History); n == 0 || sh.History[n-1] != line {
+		sh.History = append(sh.History, line)
+	}
+	args, err := splitArgs(line)
+	if err != nil {
+		fmt.Fprintf(out, "Error: %v\n", err)
+		return 1, false
+	}
+	switch args[0] {
+	case "exit":
+		if len(args) > 2 {
+			fmt.Fprintln(out, "Error: exit accepts at most 1 argument(s)")
+			return 2, false
+		} else if len(args) == 2 {
+			if code, err = strconv.Atoi(args[1]); err != nil {
+				fmt.Fprintf(out, "Error: invalid exit code %q\n", args[1])
+				return 2, false
+			}
+		}
+		return code, true
+	case "history":
+		for i, line := range sh.History {
+			fmt.Fprintf(out, "%5d  %s\n", i+1, line)
+		}
+		return 0, false
+	}
+	if code = dispatch(sh.Cfg, out, args); len(args) == 1 && isHelp(args[0]) {
+		io.WriteString(out, Dedent(`
+			Shell commands:
+			  exit [code]  End the session
+			  history      List previous command lines
+			  !!           Repeat the last command line
+			  !<n>         Repeat command line n
+		`)[1:]+"\n")
+	}
+	return code, false
+}
+
+// dispatch calls c.Execute and intercepts Exit for the duration of the command.
+func dispatch(c *Cfg, out io.Writer, args []string) (code int) {
+	exit, exited := Exit, false
+	defer func() {
+		Exit = exit
+		if p := recover(); p != nil {
+			if _, ok := p.(ExitPanic); !ok {
+				panic(p)
+			}
+		}
+	}()
+	Exit = func(rc int) {
+		if !exited {
+			exited, code = true, rc
+		}
+		panic(ExitPanic(rc))
+	}
+	return c.Execute(outWriter(), out, args)
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShell(t *testing.T) {
+	defer func(fn func(int)) { Exit = fn }(Exit)
+	rc := resetExit()
+	Bin = "bin"
+
+	var root Cfg
+	var ran [][]string
+	root.Add(&Cfg{Name: "echo", MaxArgs: -1, New: newTestCmd(func(args []string) error {
+		ran = append(ran, args)
+		return nil
+	})})
+	root.Add(&Cfg{Name: "fail", New: newTestCmd(func([]string) error {
+		return errors.New("failed")
+	})})
+	root.Add(&Cfg{Name: "code", New: newTestCmd(func([]string) error {
+		return ExitCode(3)
+	})})
+	root.Add(&Cfg{Name: "exit-now", New: newTestCmd(func([]string) error {
+		Exit(4)
+		panic("unreachable")
+	})})
+
+	var out bytes.Buffer
+	sh := Shell{Cfg: &root, In: strings.NewReader(Dedent(`
+		echo a 'b c'
+
+		echo "d
+		e" f\
+		g
+		fail
+		code
+		exit-now
+		echo -x
+		!1
+		!!
+		!9
+		history
+		exit 5
+		echo unreachable
+	`)), Out: &out}
+	assert.Equal(t, ExitCode(5), sh.Run())
+	assert.Equal(t, -1, *rc)
+	assert.Equal(t, [][]string{
+		{"a", "b c"},
+		{"d\ne", "fg"},
+		{"a", "b c"},
+		{"a", "b c"},
+	}, ran)
+	assert.Equal(t, Dedent(`
+		Error: failed
+		Error: flag provided but not defined: -x
+		Usage: bin echo
+		       bin echo help
+		echo a 'b c'
+		echo a 'b c'
+		Error: !9: event not found
+		    1  echo a 'b c'
+		    2  echo "d
+		e" f\
+		g
+		    3  fail
+		    4  code
+		    5  exit-now
+		    6  echo -x
+		    7  echo a 'b c'
+		    8  history
+	`)[1:], out.String())
+	assert.Equal(t, []string{"echo a 'b c'", "echo \"d\ne\" f\\\ng", "fail", "code",
+		"exit-now", "echo -x", "echo a 'b c'", "history", "exit 5"}, sh.History)
+
+	out.Reset()
+	sh = Shell{Cfg: &root, Prompt: "> ", In: strings.NewReader("help\nexit\n"), Out: &out}
+	require.NoError(t, sh.Run())
+	assert.Equal(t, Dedent(`
+		> Usage: bin <command> [options] ...
+		       bin <command> help
+		       bin help [command]
+
+		Commands:
+		  code
+		  echo
+		  exit-now
+		  fail
+
+		Shell commands:
+		  exit [code]  End the session
+		  history      List previous command lines
+		  !!           Repeat the last command line
+		  !<n>         Repeat command line n
+
+		> `)[1:], out.String())
+
+	out.Reset()
+	sh = Shell{Cfg: &root, Prompt: "> ", In: strings.NewReader("echo 'x\ny'\nexit 1 2\nexit x"), Out: &out}
+	require.NoError(t, sh.Run())
+	assert.Equal(t, "> > > Error: exit accepts at most 1 argument(s)\n"+
+		"> Error: invalid exit code \"x\"\n\n", out.String())
+	assert.Equal(t, -1, *rc)
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"sync"
@@ -8,9 +9,40 @@ import (
 
 var signalsOnce sync.Once
 
+// reload contains callbacks registered via OnReload.
+var reload struct {
+	sync.Mutex
+	fns  []*func()
+	sigs chan os.Signal
+}
+
 // ExitSignals returns signals for which the default behavior is to exit without
-// a stack dump. It must be called before signal handlers are modified.
+// a stack dump. It must be called before signal handlers are modified. The
+// reload signal (SIGHUP on Unix) is excluded while any OnReload callbacks are
+// registered. The result reflects the callbacks registered at the time of the
+// call, so programs that use both must call OnReload first, before passing
+// ExitSignals to signal.Notify. ExitContext checks for callbacks when a signal
+// is received and does not have this restriction.
 func ExitSignals() []os.Signal {
+	sigs := allExitSignals()
+	if !reloading() {
+		return sigs
+	}
+	keep := make([]os.Signal, 0, len(sigs))
+	for _, s := range sigs {
+		if s != reloadSignal {
+			keep = append(keep, s)
+		}
+	}
+	if len(keep) == 0 {
+		keep = append(keep, os.Interrupt)
+	}
+	return keep
+}
+
+// allExitSignals returns exit signals that were not ignored when it was first
+// called, including the reload signal.
+func allExitSignals() []os.Signal {
 	signalsOnce.Do(func() {
 		keep := exitSignals[:0]
 		for _, s := range exitSignals {
@@ -27,3 +59,88 @@ func ExitSignals() []os.Signal {
 	})
 	return exitSignals
 }
+
+// ExitContext returns a copy of parent that is canceled when the process
+// receives one of ExitSignals. Default signal behavior is restored after the
+// first signal, so a second one terminates the process. Calling stop releases
+// resources and restores default signal behavior. The reload signal does not
+// cancel the context while any OnReload callbacks are registered.
+func ExitContext(parent context.Context) (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancel(parent)
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, allExitSignals()...)
+	go func() {
+		for {
+			select {
+			case s := <-sigs:
+				if s == reloadSignal && reloading() {
+					continue
+				}
+				signal.Stop(sigs)
+				cancel()
+			case <-ctx.Done():
+				signal.Stop(sigs)
+			}
+			return
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(sigs)
+		cancel()
+	}
+}
+
+// OnReload registers fn to be called when the process receives the reload
+// signal, which is SIGHUP on Unix. While any callbacks are registered, the
+// reload signal does not terminate the process and is not one of ExitSignals.
+// Callbacks are called sequentially in registration order from a separate
+// goroutine. The returned function unregisters fn. On platforms without a
+// reload signal, fn is never called.
+func OnReload(fn func()) (unregister func()) {
+	if reloadSignal == nil {
+		return func() {}
+	}
+	allExitSignals() // Must be called before signal handlers are modified
+	p := &fn
+	reload.Lock()
+	defer reload.Unlock()
+	if reload.fns = append(reload.fns, p); reload.sigs == nil {
+		reload.sigs = make(chan os.Signal, 1)
+		signal.Notify(reload.sigs, reloadSignal)
+		go reloadLoop(reload.sigs)
+	}
+	return func() {
+		reload.Lock()
+		defer reload.Unlock()
+		for i, q := range reload.fns {
+			if q == p {
+				reload.fns = append(reload.fns[:i], reload.fns[i+1:]...)
+				break
+			}
+		}
+		if len(reload.fns) == 0 && reload.sigs != nil {
+			signal.Stop(reload.sigs)
+			close(reload.sigs)
+			reload.sigs = nil
+		}
+	}
+}
+
+// reloading returns whether any OnReload callbacks are registered.
+func reloading() bool {
+	reload.Lock()
+	defer reload.Unlock()
+	return len(reload.fns) > 0
+}
+
+// reloadLoop calls OnReload callbacks for each signal received from sigs.
+func reloadLoop(sigs <-chan os.Signal) {
+	for range sigs {
+		reload.Lock()
+		fns := append(([]*func())(nil), reload.fns...)
+		reload.Unlock()
+		for _, fn := range fns {
+			(*fn)()
+		}
+	}
+}
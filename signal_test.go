@@ -1,13 +1,16 @@
 package cli
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"runtime"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestExitSignals(t *testing.T) {
@@ -34,3 +37,48 @@ func TestExitSignals(t *testing.T) {
 	signalsOnce = sync.Once{}
 	assert.Equal(t, []os.Signal{os.Interrupt}, ExitSignals())
 }
+
+func TestOnReload(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		OnReload(func() { t.Fatal("reload") })()
+		return
+	}
+	defer func(s []os.Signal) { exitSignals, signalsOnce = s, sync.Once{} }(exitSignals)
+	exitSignals, signalsOnce = []os.Signal{reloadSignal, os.Interrupt}, sync.Once{}
+	signalsOnce.Do(func() {}) // Reload signal may be ignored by the test runner
+	all := []os.Signal{reloadSignal, os.Interrupt}
+	require.Equal(t, all, ExitSignals())
+	self, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+
+	ctx, stop := ExitContext(context.Background())
+	defer stop()
+	ch := make(chan int, 2)
+	unregister1 := OnReload(func() { ch <- 1 })
+	unregister2 := OnReload(func() { ch <- 2 })
+	assert.NotContains(t, ExitSignals(), reloadSignal)
+
+	require.NoError(t, self.Signal(reloadSignal))
+	for _, want := range []int{1, 2} {
+		select {
+		case have := <-ch:
+			assert.Equal(t, want, have)
+		case <-time.After(5 * time.Second):
+			t.Fatal("reload timeout")
+		}
+	}
+	unregister1()
+	assert.NotContains(t, ExitSignals(), reloadSignal)
+	unregister2()
+	unregister2()
+	assert.Equal(t, all, ExitSignals())
+	assert.NoError(t, ctx.Err())
+
+	require.NoError(t, self.Signal(os.Interrupt))
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancel timeout")
+	}
+	stop()
+}
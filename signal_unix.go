@@ -8,3 +8,5 @@ import (
 )
 
 var exitSignals = []os.Signal{syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM}
+
+var reloadSignal os.Signal = syscall.SIGHUP
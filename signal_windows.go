@@ -3,3 +3,5 @@ package cli
 import "os"
 
 var exitSignals = []os.Signal{os.Interrupt}
+
+var reloadSignal os.Signal
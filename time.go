@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// now returns the current time for relative time expressions.
+var now = time.Now
+
+// timeLayouts are the layouts accepted for time.Time flags in addition to the
+// one specified in the field tag. Layouts without a zone are interpreted in
+// the location specified by the "tz" tag option (local time by default).
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseTime returns a parseFunc for time.Time values. In addition to absolute
+// times in the specified layout or one of timeLayouts, it accepts "now",
+// "today", "yesterday", "tomorrow", and signed durations relative to the
+// current time (e.g. "-2h" or "+1d"). If loc is not nil, it specifies the time
+// zone location for parsing and the returned times.
+func parseTime(layout string, loc *time.Location) parseFunc {
+	layouts := timeLayouts
+	if layout != "" {
+		layouts = append([]string{layout}, layouts...)
+	}
+	return func(s string) (reflect.Value, error) {
+		in := loc
+		if in == nil {
+			in = time.Local
+		}
+		t, err := parseTimeIn(s, layouts, in)
+		if err == nil && loc != nil {
+			t = t.In(loc)
+		}
+		return reflect.ValueOf(t), err
+	}
+}
+
+// parseTimeIn parses s using the specified layouts and location.
+func parseTimeIn(s string, layouts []string, loc *time.Location) (time.Time, error) {
+	t := now().In(loc)
+	switch midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc); strings.ToLower(s) {
+	case "now":
+		return t, nil
+	case "today":
+		return midnight, nil
+	case "yesterday":
+		return midnight.AddDate(0, 0, -1), nil
+	case "tomorrow":
+		return midnight.AddDate(0, 0, 1), nil
+	}
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		d, err := parseDuration(s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cli: invalid relative time %q", s)
+		}
+		return t.Add(d), nil
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cli: invalid time %q", s)
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeFlags(t *testing.T) {
+	defer func() { now = time.Now }()
+	ref := time.Date(2020, 3, 15, 10, 30, 0, 0, time.UTC)
+	now = func() time.Time { return ref }
+
+	var v struct {
+		Since time.Time  `cli:",tz=UTC,"`
+		Until *time.Time `cli:",layout=01/02/2006,tz=UTC,"`
+		Local time.Time  `cli:""`
+	}
+	fs := newFlagSet(&v)
+	assert.Equal(t, "", fs.Lookup("since").DefValue)
+	assert.Equal(t, "", fs.Lookup("until").DefValue)
+	w := newWriter(nil)
+	w.flags(fs.flags)
+	assert.Contains(t, w.String(), "  -since time\n")
+	assert.Contains(t, w.String(), "  -until time\n")
+
+	utc := func(s string) time.Time {
+		t.Helper()
+		require.NoError(t, fs.Set("since", s), "%q", s)
+		return v.Since
+	}
+	assert.Equal(t, time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC), utc("2019-12-31"))
+	assert.Equal(t, time.Date(2019, 12, 31, 23, 59, 0, 0, time.UTC), utc("2019-12-31 23:59"))
+	assert.Equal(t, time.Date(2019, 12, 31, 23, 59, 1, 0, time.UTC), utc("2019-12-31T23:59:01"))
+	assert.Equal(t, time.Date(2020, 1, 1, 1, 0, 0, 5, time.UTC), utc("2020-01-01T02:00:00.000000005+01:00"))
+	assert.Equal(t, ref, utc("now"))
+	assert.Equal(t, time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC), utc("Today"))
+	assert.Equal(t, time.Date(2020, 3, 14, 0, 0, 0, 0, time.UTC), utc("yesterday"))
+	assert.Equal(t, time.Date(2020, 3, 16, 0, 0, 0, 0, time.UTC), utc("tomorrow"))
+	assert.Equal(t, ref.Add(-2*time.Hour), utc("-2h"))
+	assert.Equal(t, ref.Add(7*day), utc("+1w"))
+	assert.Equal(t, "2020-03-22T10:30:00Z", fs.Lookup("since").Value.String())
+
+	require.NoError(t, fs.Set("until", "04/05/2021"))
+	assert.Equal(t, time.Date(2021, 4, 5, 0, 0, 0, 0, time.UTC), *v.Until)
+	require.NoError(t, fs.Set("until", "2021-04-06"))
+	assert.Equal(t, time.Date(2021, 4, 6, 0, 0, 0, 0, time.UTC), *v.Until)
+
+	require.NoError(t, fs.Set("local", "2021-04-05"))
+	assert.Equal(t, time.Date(2021, 4, 5, 0, 0, 0, 0, time.Local), v.Local)
+
+	assert.EqualError(t, fs.Set("since", "x"), `cli: invalid time "x"`)
+	assert.EqualError(t, fs.Set("since", "-x"), `cli: invalid relative time "-x"`)
+
+	type BadTZ struct {
+		T time.Time `cli:",tz=Nowhere/Invalid,"`
+	}
+	assert.PanicsWithValue(t, "cli: invalid time zone for flag t: unknown time zone Nowhere/Invalid",
+		func() { NewFlagSet(new(BadTZ)) })
+}
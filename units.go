@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ByteSize is a number of bytes. It is parsed and formatted with optional
+// decimal (k, M, G, T, P, E) or binary (Ki, Mi, Gi, Ti, Pi, Ei) unit prefixes
+// and an optional 'B' suffix (e.g. "10MiB" or "1.5G"). Unit prefixes are not
+// case-sensitive.
+type ByteSize uint64
+
+// byteUnits contains all ByteSize units in descending order.
+var byteUnits = [...]struct {
+	name string
+	size ByteSize
+}{
+	{"EiB", 1 << 60}, {"EB", 1e18},
+	{"PiB", 1 << 50}, {"PB", 1e15},
+	{"TiB", 1 << 40}, {"TB", 1e12},
+	{"GiB", 1 << 30}, {"GB", 1e9},
+	{"MiB", 1 << 20}, {"MB", 1e6},
+	{"KiB", 1 << 10}, {"kB", 1e3},
+}
+
+// String implements flag.Value. It uses the largest unit that can represent b
+// exactly with at most two decimal places.
+func (b ByteSize) String() string {
+	for _, u := range byteUnits {
+		if b < u.size {
+			continue
+		}
+		step := u.size / 100 // Size of the second decimal place
+		if u.name[1] == 'i' {
+			step = u.size / 4 // 100 = 4*25, and 25 is odd
+		}
+		if b%step != 0 {
+			continue
+		}
+		n, frac := strconv.FormatUint(uint64(b/u.size), 10), ""
+		if r := b % u.size; r != 0 {
+			frac = fmt.Sprintf(".%02d", uint64(r/step)*uint64(100/(u.size/step)))
+			frac = strings.TrimRight(frac, "0")
+		}
+		return n + frac + u.name
+	}
+	return strconv.FormatUint(uint64(b), 10) + "B"
+}
+
+// Set implements flag.Value.
+func (b *ByteSize) Set(s string) error {
+	i := strings.IndexFunc(s, func(c rune) bool {
+		return c != '.' && (c < '0' || '9' < c)
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	num, unit := s[:i], strings.TrimSpace(s[i:])
+	mul := ByteSize(1)
+	if unit = strings.TrimSuffix(strings.ToLower(unit), "b"); unit != "" {
+		mul = 0
+		for _, u := range byteUnits {
+			if strings.EqualFold(strings.TrimSuffix(u.name, "B"), unit) {
+				mul = u.size
+				break
+			}
+		}
+	}
+	if mul == 0 || num == "" {
+		return fmt.Errorf("cli: invalid byte size %q", s)
+	}
+	if strings.IndexByte(num, '.') < 0 {
+		n, err := strconv.ParseUint(num, 10, 64)
+		if err != nil || n > math.MaxUint64/uint64(mul) {
+			return fmt.Errorf("cli: invalid byte size %q", s)
+		}
+		*b = ByteSize(n) * mul
+		return nil
+	}
+	// Use exact arithmetic to avoid float64 rounding errors for large units
+	r, ok := new(big.Rat).SetString(num)
+	if !ok {
+		return fmt.Errorf("cli: invalid byte size %q", s)
+	}
+	r.Mul(r, new(big.Rat).SetUint64(uint64(mul)))
+	n, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Lsh(rem, 1).Cmp(r.Denom()) >= 0 {
+		n.Add(n, big.NewInt(1))
+	}
+	if !n.IsUint64() {
+		return fmt.Errorf("cli: invalid byte size %q", s)
+	}
+	*b = ByteSize(n.Uint64())
+	return nil
+}
+
+// Get implements flag.Getter.
+func (b ByteSize) Get() interface{} { return b }
+
+func (ByteSize) typeName() string { return "size" }
+
+// Percent is a percentage value, such that 50% is represented as 50. It is
+// parsed with an optional '%' suffix.
+type Percent float64
+
+// Fraction returns p as a fraction of one (e.g. 0.5 for 50%).
+func (p Percent) Fraction() float64 { return float64(p) / 100 }
+
+// String implements flag.Value.
+func (p Percent) String() string {
+	return strconv.FormatFloat(float64(p), 'g', -1, 64) + "%"
+}
+
+// Set implements flag.Value.
+func (p *Percent) Set(s string) error {
+	f, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil {
+		return fmt.Errorf("cli: invalid percentage %q", s)
+	}
+	*p = Percent(f)
+	return nil
+}
+
+// Get implements flag.Getter.
+func (p Percent) Get() interface{} { return p }
+
+func (Percent) typeName() string { return "percent" }
+
+// Duration is a time.Duration that also accepts day ('d' = 24h) and week
+// ('w' = 7d) units (e.g. "1w2d12h").
+type Duration time.Duration
+
+const day = 24 * time.Hour
+
+// String implements flag.Value. Durations of a day or longer are formatted
+// with a day unit, and zero minutes and seconds are omitted (e.g. "7d12h").
+func (d Duration) String() string {
+	v, sign := time.Duration(d), ""
+	if v < 0 {
+		v, sign = -v, "-"
+	}
+	var s string
+	if v < day {
+		s = v.String()
+	} else if s = strconv.FormatInt(int64(v/day), 10) + "d"; v%day != 0 {
+		s += (v % day).String()
+	}
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return sign + s
+}
+
+// Set implements flag.Value.
+func (d *Duration) Set(s string) error {
+	v, err := parseDuration(s)
+	if err == nil {
+		*d = Duration(v)
+	}
+	return err
+}
+
+// Get implements flag.Getter.
+func (d Duration) Get() interface{} { return time.Duration(d) }
+
+func (Duration) typeName() string { return "duration" }
+
+// parseDuration parses s as a Duration.
+func parseDuration(s string) (time.Duration, error) {
+	in, sign := s, time.Duration(1)
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		if s[0] == '-' {
+			sign = -1
+		}
+		s = s[1:]
+	}
+	var days float64
+	var hasDays bool
+	var rest strings.Builder
+	for s != "" {
+		i := strings.IndexFunc(s, func(c rune) bool {
+			return c != '.' && (c < '0' || '9' < c)
+		})
+		if i <= 0 {
+			rest.WriteString(s)
+			break
+		}
+		j := strings.IndexFunc(s[i:], func(c rune) bool {
+			return c == '.' || '0' <= c && c <= '9'
+		})
+		if j < 0 {
+			j = len(s) - i
+		}
+		switch unit := s[i : i+j]; unit {
+		case "d", "w":
+			n, err := strconv.ParseFloat(s[:i], 64)
+			if err != nil {
+				return 0, fmt.Errorf("cli: invalid duration %q", in)
+			}
+			if unit == "w" {
+				n *= 7
+			}
+			days, hasDays = days+n, true
+		default:
+			rest.WriteString(s[:i+j])
+		}
+		s = s[i+j:]
+	}
+	var v time.Duration
+	if rest.Len() > 0 || !hasDays {
+		var err error
+		if v, err = time.ParseDuration(rest.String()); err != nil {
+			return 0, fmt.Errorf("cli: invalid duration %q", in)
+		}
+	}
+	return sign * (v + time.Duration(days*float64(day))), nil
+}
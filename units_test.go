@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestByteSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ByteSize
+		out  string
+	}{
+		{"0", 0, "0B"},
+		{"1023", 1023, "1023B"},
+		{"1000", 1000, "1kB"},
+		{"1k", 1000, "1kB"},
+		{"1.5KiB", 1536, "1.5KiB"},
+		{"10MiB", 10 << 20, "10MiB"},
+		{"10mib", 10 << 20, "10MiB"},
+		{"1.5G", 1500e6, "1.5GB"},
+		{"1.25 GB", 1250e6, "1.25GB"},
+		{"1.75gi", 7 << 28, "1.75GiB"},
+		{"1234567B", 1234567, "1234567B"},
+		{"16EiB", 0, ""},
+		{"15EiB", 15 << 60, "15EiB"},
+		{"1.5EiB", 3 << 59, "1.5EiB"},
+		{"1.25PiB", 5 << 48, "1.25PiB"},
+		{"1.5EB", 15e17, "1.5EB"},
+		{"18.44EB", 1844e16, "18.44EB"},
+	}
+	for _, tc := range tests {
+		var b ByteSize
+		err := b.Set(tc.in)
+		if tc.out == "" {
+			assert.Error(t, err, "%q", tc.in)
+			continue
+		}
+		require.NoError(t, err, "%q", tc.in)
+		assert.Equal(t, tc.want, b, "%q", tc.in)
+		assert.Equal(t, tc.out, b.String(), "%q", tc.in)
+		var rt ByteSize
+		require.NoError(t, rt.Set(b.String()))
+		assert.Equal(t, b, rt, "%q", tc.in)
+	}
+	for _, in := range []string{"", "k", "-1", "1x", "1.2.3M", "1bb"} {
+		var b ByteSize
+		assert.Error(t, b.Set(in), "%q", in)
+	}
+}
+
+func TestPercent(t *testing.T) {
+	var p Percent
+	require.NoError(t, p.Set("50%"))
+	assert.Equal(t, Percent(50), p)
+	assert.Equal(t, 0.5, p.Fraction())
+	assert.Equal(t, "50%", p.String())
+	require.NoError(t, p.Set("7.5"))
+	assert.Equal(t, "7.5%", p.String())
+	assert.EqualError(t, p.Set("x%"), `cli: invalid percentage "x%"`)
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		out  string
+	}{
+		{"0", 0, "0s"},
+		{"1s", time.Second, "1s"},
+		{"90s", 90 * time.Second, "1m30s"},
+		{"2h", 2 * time.Hour, "2h"},
+		{"7d", 7 * day, "7d"},
+		{"1w", 7 * day, "7d"},
+		{"1.5d", 36 * time.Hour, "1d12h"},
+		{"1w2d3h4m", 9*day + 3*time.Hour + 4*time.Minute, "9d3h4m"},
+		{"-2d1s", -2*day - time.Second, "-2d1s"},
+		{"+1d", day, "1d"},
+		{"0d", 0, "0s"},
+		{"1d500ms", day + 500*time.Millisecond, "1d500ms"},
+	}
+	for _, tc := range tests {
+		var d Duration
+		require.NoError(t, d.Set(tc.in), "%q", tc.in)
+		assert.Equal(t, tc.want, d.Get(), "%q", tc.in)
+		assert.Equal(t, tc.out, d.String(), "%q", tc.in)
+		var rt Duration
+		require.NoError(t, rt.Set(d.String()))
+		assert.Equal(t, d, rt, "%q", tc.in)
+	}
+	for _, in := range []string{"", "d", "1", "1x", "1.2.3d", "-"} {
+		var d Duration
+		assert.Error(t, d.Set(in), "%q", in)
+	}
+}
+
+func TestUnitFlags(t *testing.T) {
+	var v struct {
+		Size    ByteSize        `cli:""`
+		Pct     Percent         `cli:""`
+		Timeout time.Duration   `cli:""`
+		Retain  *time.Duration  `cli:""`
+		Periods []time.Duration `cli:",split,"`
+	}
+	v.Size = 1 << 20
+	fs := newFlagSet(&v)
+	assert.Equal(t, "1MiB", fs.Lookup("size").DefValue)
+	require.NoError(t, fs.parse(split("-size=2G -pct=10% -timeout=1d -retain=2w -periods=1d,1h")))
+	assert.Equal(t, ByteSize(2e9), v.Size)
+	assert.Equal(t, Percent(10), v.Pct)
+	assert.Equal(t, day, v.Timeout)
+	assert.Equal(t, 14*day, *v.Retain)
+	assert.Equal(t, []time.Duration{day, time.Hour}, v.Periods)
+	assert.Equal(t, "1d", fs.Lookup("timeout").Value.String())
+	assert.Equal(t, "14d", fs.Lookup("retain").Value.String())
+
+	w := newWriter(nil)
+	w.flags(fs.flags)
+	assert.Equal(t, Dedent(`
+		  -pct percent
+		    	
+		  -periods value
+		    	
+		  -retain duration
+		    	
+		  -size size
+		    	 (default 1MiB)
+		  -timeout duration
+		    	
+	`)[1:], w.String())
+}
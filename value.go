@@ -0,0 +1,356 @@
+package cli
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseFunc converts a string to a value of a specific type.
+type parseFunc func(s string) (reflect.Value, error)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	flagValue    = reflect.TypeOf((*flag.Value)(nil)).Elem()
+	textValue    = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// typeParsers contains parsers for types that do not implement flag.Value or
+// encoding.TextUnmarshaler.
+var typeParsers = map[reflect.Type]parseFunc{
+	durationType: func(s string) (reflect.Value, error) {
+		d, err := parseDuration(s)
+		return reflect.ValueOf(d), err
+	},
+	ipNetType: parseIPNet,
+	macType:   parseMAC,
+	urlType:   parseURL(""),
+}
+
+// parser returns a parseFunc for values of type t or nil if t is not
+// supported. Basic types are supported in addition to those accepted by
+// textParser.
+func parser(t reflect.Type) parseFunc {
+	if parse := textParser(t); parse != nil {
+		return parse
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return func(s string) (reflect.Value, error) {
+			b, err := strconv.ParseBool(s)
+			return reflect.ValueOf(b).Convert(t), err
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(s string) (reflect.Value, error) {
+			i, err := strconv.ParseInt(s, 0, t.Bits())
+			return reflect.ValueOf(i).Convert(t), err
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(s string) (reflect.Value, error) {
+			u, err := strconv.ParseUint(s, 0, t.Bits())
+			return reflect.ValueOf(u).Convert(t), err
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(s string) (reflect.Value, error) {
+			f, err := strconv.ParseFloat(s, t.Bits())
+			return reflect.ValueOf(f).Convert(t), err
+		}
+	case reflect.String:
+		return func(s string) (reflect.Value, error) {
+			return reflect.ValueOf(s).Convert(t), nil
+		}
+	}
+	return nil
+}
+
+// textParser returns a parseFunc for types whose pointers implement flag.Value
+// or encoding.TextUnmarshaler, and for types in typeParsers. It returns nil for
+// all other types.
+func textParser(t reflect.Type) parseFunc {
+	switch p := reflect.PtrTo(t); {
+	case p.Implements(flagValue):
+		return func(s string) (reflect.Value, error) {
+			v := reflect.New(t)
+			err := v.Interface().(flag.Value).Set(s)
+			return v.Elem(), err
+		}
+	case p.Implements(textValue):
+		return func(s string) (reflect.Value, error) {
+			v := reflect.New(t)
+			err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+			return v.Elem(), err
+		}
+	}
+	return typeParsers[t]
+}
+
+// format returns the string representation of v, which is expected to be
+// accepted by the parseFunc for v's type.
+func format(v reflect.Value) string {
+	if v.Type() == durationType {
+		return Duration(v.Int()).String()
+	}
+	if v.Type() == timeType {
+		if t := v.Interface().(time.Time); !t.IsZero() {
+			return t.Format(time.RFC3339Nano)
+		}
+		return ""
+	}
+	if !v.CanAddr() {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		v = p.Elem()
+	}
+	switch x := v.Addr().Interface().(type) {
+	case fmt.Stringer:
+		return x.String()
+	case encoding.TextMarshaler:
+		if b, err := x.MarshalText(); err == nil {
+			return string(b)
+		}
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())
+	case reflect.String:
+		return v.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// anyValue implements flag.Value for any type supported by parser and for
+// pointers to such types. Pointers are allocated by Set, leaving nil as an
+// indication that the flag was not set.
+type anyValue struct {
+	v     reflect.Value // Addressable value or pointer
+	parse parseFunc     // Value or pointer element parser
+}
+
+func (p *anyValue) String() string {
+	if p == nil || !p.v.IsValid() || p.v.Kind() == reflect.Ptr && p.v.IsNil() {
+		return ""
+	}
+	return format(reflect.Indirect(p.v))
+}
+
+func (p *anyValue) Set(s string) error {
+	v, err := p.parse(s)
+	if err != nil {
+		return err
+	}
+	if p.v.Type() == v.Type() {
+		p.v.Set(v)
+	} else if v.CanAddr() {
+		p.v.Set(v.Addr())
+	} else {
+		p.v.Set(reflect.New(v.Type()))
+		p.v.Elem().Set(v)
+	}
+	return nil
+}
+
+func (p *anyValue) Get() interface{} { return p.v.Interface() }
+
+func (p *anyValue) typeName() string {
+	if t := p.v.Type(); t == timeType || t.Kind() == reflect.Ptr && t.Elem() == timeType {
+		return "time"
+	}
+	return "value"
+}
+
+// sliceValue implements flag.Value for slices of any type supported by
+// parser. The first occurrence of the flag replaces the default value and each
+// subsequent one appends one or more values.
+type sliceValue struct {
+	v     reflect.Value // Addressable slice
+	parse parseFunc     // Element parser
+	split bool          // Split each argument on commas
+	set   bool          // Default value was replaced
+}
+
+// newSliceValue returns a sliceValue for slice v or nil if the slice element
+// type is not supported by parser.
+func newSliceValue(v reflect.Value, parser func(reflect.Type) parseFunc, split bool) *sliceValue {
+	if parse := parser(v.Type().Elem()); parse != nil {
+		return &sliceValue{v: v, parse: parse, split: split}
+	}
+	return nil
+}
+
+func (p *sliceValue) String() string {
+	if p == nil || !p.v.IsValid() || p.v.Len() == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for i := 0; i < p.v.Len(); i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(format(p.v.Index(i)))
+	}
+	return sb.String()
+}
+
+func (p *sliceValue) Set(s string) error {
+	vals := []string{s}
+	if p.split {
+		vals = strings.Split(s, ",")
+	}
+	elems := make([]reflect.Value, len(vals))
+	for i, s := range vals {
+		e, err := p.parse(s)
+		if err != nil {
+			return err
+		}
+		elems[i] = e
+	}
+	if !p.set {
+		p.v.Set(reflect.Zero(p.v.Type()))
+		p.set = true
+	}
+	p.v.Set(reflect.Append(p.v, elems...))
+	return nil
+}
+
+func (p *sliceValue) Get() interface{} { return p.v.Interface() }
+
+func (p *sliceValue) IsBoolFlag() bool { return p.v.Type().Elem().Kind() == reflect.Bool }
+
+// Duplicate map key policies.
+const (
+	dupOverwrite = "overwrite"
+	dupAppend    = "append"
+	dupError     = "error"
+)
+
+// mapValue implements flag.Value for maps with string keys and values of any
+// type supported by parser, including slices of such types. Each argument has
+// the format "key=value" or "k1=v1,k2=v2" if split is enabled. For slice values,
+// split elements without '=' are additional values for the preceding key (e.g.
+// "k1=a,b,k2=c"). The first occurrence of the flag replaces the default map.
+// String formats slice values as repeated "key=value" pairs.
+type mapValue struct {
+	v     reflect.Value   // Addressable map
+	parse parseFunc       // Value or slice element parser
+	split bool            // Split each argument on commas
+	dup   string          // Duplicate key policy
+	seen  map[string]bool // Keys set by Set
+}
+
+// newMapValue returns a mapValue for map v or nil if the map key or value type
+// is not supported by parser. The default dup policy is to append slice values
+// and to overwrite all others.
+func newMapValue(v reflect.Value, parser func(reflect.Type) parseFunc, split bool, dup string) *mapValue {
+	t := v.Type()
+	if t.Key().Kind() != reflect.String {
+		return nil
+	}
+	elem, isSlice := t.Elem(), t.Elem().Kind() == reflect.Slice
+	if isSlice {
+		elem = elem.Elem()
+	}
+	parse := parser(elem)
+	if parse == nil {
+		return nil
+	}
+	switch dup {
+	case "":
+		if dup = dupOverwrite; isSlice {
+			dup = dupAppend
+		}
+	case dupAppend:
+		if !isSlice {
+			panic("cli: dup=append requires slice map values: " + t.String())
+		}
+	case dupOverwrite, dupError:
+	default:
+		panic("cli: invalid dup policy: " + dup)
+	}
+	return &mapValue{v: v, parse: parse, split: split, dup: dup}
+}
+
+func (p *mapValue) String() string {
+	if p == nil || !p.v.IsValid() {
+		return "{}"
+	}
+	keys := make([]string, 0, p.v.Len())
+	for _, k := range p.v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	sb.Grow(2 + 16*len(keys))
+	sb.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		v := p.v.MapIndex(reflect.ValueOf(k).Convert(p.v.Type().Key()))
+		if v.Kind() != reflect.Slice {
+			sb.WriteString(k)
+			sb.WriteByte('=')
+			sb.WriteString(format(v))
+			continue
+		}
+		for j := 0; j < v.Len(); j++ {
+			if j > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(k)
+			sb.WriteByte('=')
+			sb.WriteString(format(v.Index(j)))
+		}
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func (p *mapValue) Set(s string) error {
+	pairs := []string{s}
+	if p.split {
+		pairs = strings.Split(s, ",")
+	}
+	t := p.v.Type()
+	if p.seen == nil {
+		p.v.Set(reflect.MakeMap(t))
+		p.seen = make(map[string]bool)
+	}
+	isSlice := t.Elem().Kind() == reflect.Slice
+	var key string
+	for j, kv := range pairs {
+		val, cont := kv, false
+		if i := strings.IndexByte(kv, '='); i >= 0 {
+			key, val = kv[:i], kv[i+1:]
+		} else if cont = isSlice && j > 0; !cont {
+			return fmt.Errorf("cli: missing '=' in %q", kv)
+		}
+		if !cont && p.seen[key] && p.dup == dupError {
+			return fmt.Errorf("cli: duplicate key %q", key)
+		}
+		v, err := p.parse(val)
+		if err != nil {
+			return err
+		}
+		k := reflect.ValueOf(key).Convert(t.Key())
+		if isSlice {
+			var s reflect.Value
+			if cont || p.dup == dupAppend {
+				s = p.v.MapIndex(k)
+			}
+			if !s.IsValid() {
+				s = reflect.Zero(t.Elem())
+			}
+			v = reflect.Append(s, v)
+		}
+		p.v.SetMapIndex(k, v)
+		p.seen[key] = true
+	}
+	return nil
+}
+
+func (p *mapValue) Get() interface{} { return p.v.Interface() }
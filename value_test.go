@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser(t *testing.T) {
+	tests := []struct {
+		in   string
+		want interface{}
+		out  string
+	}{
+		{"true", true, ""},
+		{"-8", int8(-8), ""},
+		{"0x10", 16, "16"},
+		{"7", uint16(7), ""},
+		{"1.5", float32(1.5), ""},
+		{"s", strType("s"), ""},
+		{"1h", time.Hour, ""},
+		{"2d", 2 * 24 * time.Hour, ""},
+		{"y", XY('Y'), "Y"},
+		{"127.0.0.1", net.IPv4(127, 0, 0, 1), ""},
+	}
+	for _, tc := range tests {
+		parse := parser(reflect.TypeOf(tc.want))
+		require.NotNil(t, parse, "%T", tc.want)
+		v, err := parse(tc.in)
+		require.NoError(t, err, "%T", tc.want)
+		assert.Equal(t, tc.want, v.Interface())
+		if tc.out == "" {
+			tc.out = tc.in
+		}
+		assert.Equal(t, tc.out, format(v), "%T", tc.want)
+	}
+	assert.Nil(t, parser(reflect.TypeOf([]int(nil))))
+	assert.Nil(t, parser(reflect.TypeOf(struct{}{})))
+
+	_, err := parser(reflect.TypeOf(0))("x")
+	assert.Error(t, err)
+}
+
+func TestSliceValue(t *testing.T) {
+	var v struct {
+		A []string `cli:""`
+		B []string `cli:",split,"`
+		C []int    `cli:",split,"`
+	}
+	fs := newFlagSet(&v)
+	require.NoError(t, fs.parse(split("-a=1,2 -b=1,2 -b=3 -c=4,5")))
+	assert.Equal(t, []string{"1,2"}, v.A)
+	assert.Equal(t, []string{"1", "2", "3"}, v.B)
+	assert.Equal(t, []int{4, 5}, v.C)
+	assert.Error(t, fs.parse(split("-c=6,x")))
+	assert.Equal(t, []int{4, 5}, v.C)
+
+	// The first value replaces the default
+	def := []int{1, 2}
+	v.C = def
+	fs = newFlagSet(&v)
+	assert.Equal(t, "1,2", fs.Lookup("c").DefValue)
+	require.NoError(t, fs.parse(split("-c=3 -c=4,5")))
+	assert.Equal(t, []int{3, 4, 5}, v.C)
+	assert.Equal(t, []int{1, 2}, def)
+
+	type Bad struct {
+		S []struct{} `cli:""`
+	}
+	assert.PanicsWithValue(t, "cli: unsupported flag type: []struct {}",
+		func() { NewFlagSet(new(Bad)) })
+	assert.Equal(t, "", (*sliceValue)(nil).String())
+}
+
+func TestMapValue(t *testing.T) {
+	var v struct {
+		Over  map[string]int      `cli:""`
+		Err   map[string]string   `cli:",split,dup=error,"`
+		App   map[string][]int    `cli:",split,"`
+		Repl  map[string][]string `cli:",dup=overwrite,"`
+		List  map[string][]string `cli:",split,dup=overwrite,"`
+		Typed map[strType]XY      `cli:""`
+	}
+	def := map[string]string{"a": "default", "c": "default"}
+	v.Err = def
+	fs := newFlagSet(&v)
+	assert.Equal(t, "{a=default c=default}", fs.Lookup("err").DefValue)
+	require.NoError(t, fs.parse(split(
+		"-over=a=1 -over=a=2 -err=a=1,b=2 -app=a=1,a=2 -app=b=3 -repl=a=1 -repl=a=2 -typed=k=y")))
+	assert.Equal(t, map[string]int{"a": 2}, v.Over)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, v.Err)
+	assert.Equal(t, map[string]string{"a": "default", "c": "default"}, def)
+	assert.Equal(t, map[string][]int{"a": {1, 2}, "b": {3}}, v.App)
+	assert.Equal(t, map[string][]string{"a": {"2"}}, v.Repl)
+	assert.Equal(t, map[strType]XY{"k": 'Y'}, v.Typed)
+
+	require.NoError(t, fs.parse(split("-app=a=3,4,c=5 -list=a=1,2,b=3 -list=a=4,5")))
+	assert.Equal(t, map[string][]int{"a": {1, 2, 3, 4}, "b": {3}, "c": {5}}, v.App)
+	assert.Equal(t, map[string][]string{"a": {"4", "5"}, "b": {"3"}}, v.List)
+	assert.Equal(t, "{a=1 a=2 a=3 a=4 b=3 c=5}", fs.Lookup("app").Value.String())
+	assert.EqualError(t, fs.Set("app", "1,a=2"), `cli: missing '=' in "1"`)
+	assert.EqualError(t, fs.Set("err", "c=1,2"), `cli: missing '=' in "2"`)
+
+	assert.EqualError(t, fs.Set("err", "b=3"), `cli: duplicate key "b"`)
+	assert.EqualError(t, fs.Set("over", "x"), `cli: missing '=' in "x"`)
+	assert.Error(t, fs.Set("over", "x=y"))
+
+	type BadDup struct {
+		M map[string]int `cli:",dup=append,"`
+	}
+	assert.PanicsWithValue(t, "cli: dup=append requires slice map values: map[string]int",
+		func() { NewFlagSet(new(BadDup)) })
+	type BadPolicy struct {
+		M map[string]int `cli:",dup=x,"`
+	}
+	assert.PanicsWithValue(t, "cli: invalid dup policy: x",
+		func() { NewFlagSet(new(BadPolicy)) })
+	type BadKey struct {
+		M map[int]int `cli:""`
+	}
+	assert.Panics(t, func() { NewFlagSet(new(BadKey)) })
+	assert.Equal(t, "{}", (*mapValue)(nil).String())
+}
+
+func TestAnyValue(t *testing.T) {
+	var v struct {
+		IP    net.IP   `cli:""`
+		IPPtr *net.IP  `cli:""`
+		Big   big.Int  `cli:""`
+		BigP  *big.Int `cli:""`
+	}
+	v.Big.SetInt64(42)
+	fs := newFlagSet(&v)
+	assert.Equal(t, "42", fs.Lookup("big").DefValue)
+	assert.Equal(t, "", fs.Lookup("big-p").DefValue)
+	require.NoError(t, fs.parse(split(
+		"-ip=10.0.0.1 -ip-ptr=::2 -big=12345678901234567890 -big-p=-1")))
+
+	b, _ := new(big.Int).SetString("12345678901234567890", 10)
+	assert.Equal(t, net.IPv4(10, 0, 0, 1), v.IP)
+	assert.Equal(t, net.ParseIP("::2"), *v.IPPtr)
+	assert.Equal(t, b, &v.Big)
+	assert.Equal(t, big.NewInt(-1), v.BigP)
+	assert.Equal(t, "-1", fs.Lookup("big-p").Value.String())
+	assert.Error(t, fs.Set("ip", "x"))
+	assert.Equal(t, "", (*anyValue)(nil).String())
+}